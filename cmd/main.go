@@ -1,10 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"music/internal/database"
 	"music/internal/handlers"
+	"music/internal/services"
 	"music/mock"
 	"music/tools"
 	"net/http"
@@ -12,9 +14,6 @@ import (
 
 	_ "music/api"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
@@ -28,14 +27,21 @@ import (
 // @accepts         json
 // @produces        json
 func main() {
+	os.Exit(run())
+}
+
+// Запускает приложение и возвращает код завершения. os.Exit вызывается только в main,
+// чтобы отложенные вызовы успели закрыть файл логов и соединения с БД
+func run() int {
 
 	// Получаем настройки приложения
 	config := tools.GetConfig()
 
 	// Создаем файл для логов и настраиваем вывод
-	file, err := os.OpenFile("../app.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	file, err := os.OpenFile(config.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
-		log.Fatal("Failed to open log file:", err)
+		log.Println("Failed to open log file:", err)
+		return 1
 	}
 	defer file.Close()
 
@@ -45,27 +51,47 @@ func main() {
 	fatalLog := log.New(file, "FATAL\t", log.Ldate|log.Ltime)
 	tools.InitLogger(level, infoLog, errorLog, fatalLog)
 
-	//Миграци
-	db, err := database.OpenConnection(config)
-	if err != nil {
-		tools.Logger.Fatal("Failed to open db connection: ", err)
+	// Подкоманда migrate управляет миграциями и не запускает сервер
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		return runMigrate(config, os.Args[2:])
 	}
 
-	migrationDriver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		tools.Logger.Fatal("Failed to get migration driver: ", err)
-	}
+	autoMigrate := flag.Bool("automigrate", config.AutoMigrate, "apply migrations on start")
+	flag.Parse()
 
-	migrator, err := migrate.NewWithDatabaseInstance("file://../migrations", "postgres", migrationDriver)
-	if err != nil {
-		tools.Logger.Fatal("Failed to get  migrator: ", err)
-	}
+	// Выбираем хранилище песен
+	if config.Storage == "memory" {
+		services.InitRepository(database.NewMemoryRepository())
+		tools.Logger.Info("Using in-memory storage")
+	} else {
+		if *autoMigrate {
+			err = migrateDatabase(config)
+			if err != nil {
+				tools.Logger.Error("Failed to migrate: ", err)
+				return 1
+			}
+		} else {
+			tools.Logger.Info("Automatic migrations are disabled")
+		}
+
+		db, err := database.OpenPool(config)
+		if err != nil {
+			tools.Logger.Error("Failed to open db pool: ", err)
+			return 1
+		}
+		defer db.Close()
 
-	err = migrator.Up()
-	if err != nil && err != migrate.ErrNoChange {
-		tools.Logger.Fatal("Failed to migrate: ", err)
+		// Заканчиваем перенос ссылок из Song.link, при ошибке он повторится при следующем запуске
+		err = database.NormalizePendingLinks(db)
+		if err != nil {
+			tools.Logger.Error("Failed to normalize song links: ", err)
+		}
+		services.InitRepository(database.NewPostgresRepository(db))
 	}
 
+	// Запускаем очистку корзины
+	go services.RunTrashPurge()
+
 	// Запучкаем мок-сервер music_info
 	if config.MusicInfoAddr == "mock" {
 		go mock.RunServer()
@@ -76,8 +102,33 @@ func main() {
 	http.HandleFunc("/swagger/*", httpSwagger.WrapHandler)
 	http.HandleFunc("/songs", handlers.SongsHandler)
 	http.HandleFunc("/text", handlers.TextHandler)
+	http.HandleFunc("/search", handlers.SearchHandler)
+	http.HandleFunc("/trash", handlers.TrashHandler)
+	http.HandleFunc("/songs/restore", handlers.RestoreHandler)
+	http.HandleFunc("/songs/revisions", handlers.RevisionsHandler)
+	http.HandleFunc("/songs/revisions/diff", handlers.DiffHandler)
+	http.HandleFunc("/songs/revert", handlers.RevertHandler)
+	http.HandleFunc("/audit", handlers.AuditHandler)
+	http.HandleFunc("/albums", handlers.AlbumsHandler)
+	http.HandleFunc("/albums/tracks", handlers.AlbumTracksHandler)
+	http.HandleFunc("/lineup", handlers.LineupHandler)
+	http.HandleFunc("/people/groups", handlers.PersonGroupsHandler)
+	http.HandleFunc("/songs/tags", handlers.SongLabelsHandler)
+	http.HandleFunc("/songs/tags/bulk", handlers.BulkLabelsHandler)
+	http.HandleFunc("/genres", handlers.GenresHandler)
+	http.HandleFunc("/tags", handlers.TagsHandler)
+	http.HandleFunc("/playlists", handlers.PlaylistsHandler)
+	http.HandleFunc("/playlists/entries", handlers.PlaylistEntriesHandler)
+	http.HandleFunc("/users", handlers.UsersHandler)
+	http.HandleFunc("/songs/favorites", handlers.FavoritesHandler)
+	http.HandleFunc("/songs/ratings", handlers.RatingsHandler)
+	http.HandleFunc("/plays", handlers.PlaysHandler)
+	http.HandleFunc("/charts", handlers.ChartsHandler)
+	http.HandleFunc("/songs/links", handlers.SongLinksHandler)
+	http.HandleFunc("/songs/{id}", handlers.SongByIDHandler)
+	http.HandleFunc("/songs/{id}/text", handlers.SongTextByIDHandler)
 	tools.Logger.Info(fmt.Sprintf("Starting server on %s", serverAddr))
 	err = http.ListenAndServe(serverAddr, nil)
-	tools.Logger.Fatal("Server is down: ", err)
-
+	tools.Logger.Error("Server is down: ", err)
+	return 1
 }
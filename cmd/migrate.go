@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"music/internal/database"
+	"music/migrations"
+	"music/tools"
+	"os"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  up [N]     apply all or N up migrations
+  down [N]   roll back N migrations, 1 by default
+  goto V     migrate up or down to version V, 0 rolls back all migrations
+  version    print the current version
+  force V    set version V without running migrations, use to clear the dirty state (-1 - no version)`
+
+// Создает мигратор. Возвращаемая функция закрывает соединение с БД
+func newMigrator(config *tools.Config) (*migrate.Migrate, func(), error) {
+	db, err := database.OpenConnection(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrationDriver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to get migration driver: %w", err)
+	}
+
+	// Миграции встроены в исполняемый файл
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	migrator, err := migrate.NewWithInstance("iofs", source, "postgres", migrationDriver)
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to get migrator: %w", err)
+	}
+
+	return migrator, func() { migrator.Close() }, nil
+}
+
+// Применяет миграции к БД
+func migrateDatabase(config *tools.Config) error {
+	migrator, closeMigrator, err := newMigrator(config)
+	if err != nil {
+		return err
+	}
+	defer closeMigrator()
+
+	err = migrator.Up()
+	if err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+	return nil
+}
+
+// Выполняет подкоманду migrate и возвращает код завершения
+func runMigrate(config *tools.Config, args []string) int {
+	err := migrateCommand(config, args)
+	if err != nil {
+		tools.Logger.Error("Migrate command failed: ", err)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func migrateCommand(config *tools.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	command, args := args[0], args[1:]
+	// Все команды, кроме up и down, требуют ровно один аргумент или не принимают их вовсе
+	switch command {
+	case "up", "down", "goto", "force":
+		if len(args) > 1 || (command == "goto" || command == "force") && len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+	case "version":
+		if len(args) != 0 {
+			return errors.New(migrateUsage)
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	number := 0
+	if len(args) == 1 {
+		var err error
+		number, err = strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("'%s' requires a number, got '%s'", command, args[0])
+		}
+	}
+
+	migrator, closeMigrator, err := newMigrator(config)
+	if err != nil {
+		return err
+	}
+	defer closeMigrator()
+
+	switch command {
+	case "up":
+		if len(args) == 0 {
+			err = migrator.Up()
+		} else if number > 0 {
+			err = migrator.Steps(number)
+		} else {
+			return errors.New("'up' requires a positive number of migrations")
+		}
+	case "down":
+		if len(args) == 0 {
+			number = 1
+		} else if number < 1 {
+			return errors.New("'down' requires a positive number of migrations")
+		}
+		err = migrator.Steps(-number)
+	case "goto":
+		// Версии 0 у golang-migrate нет, goto 0 откатывает все миграции
+		if number < 0 {
+			return errors.New("'goto' requires a non-negative version")
+		} else if number == 0 {
+			err = migrator.Down()
+		} else {
+			err = migrator.Migrate(uint(number))
+		}
+	case "force":
+		if number < -1 {
+			return errors.New("'force' requires a version or -1")
+		}
+		err = migrator.Force(number)
+	}
+	if err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+
+	version, dirty, err := migrator.Version()
+	if err == migrate.ErrNilVersion {
+		fmt.Println("version: none")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if dirty {
+		fmt.Printf("version: %d (dirty, fix the database and run 'migrate force %d')\n", version, version)
+	} else {
+		fmt.Printf("version: %d\n", version)
+	}
+	tools.Logger.Info(fmt.Sprintf("Migrate %s done, version %d", command, version))
+	return nil
+}
@@ -0,0 +1,48 @@
+package database
+
+import "time"
+
+// Типы альбомов
+const (
+	AlbumLP          = "LP"
+	AlbumEP          = "EP"
+	AlbumSingle      = "single"
+	AlbumCompilation = "compilation"
+)
+
+// Проверяет, поддерживается ли тип альбома
+func IsAlbumType(albumType string) bool {
+	switch albumType {
+	case AlbumLP, AlbumEP, AlbumSingle, AlbumCompilation:
+		return true
+	}
+	return false
+}
+
+// Альбом группы. Альбом определяется названием и группой, как и песня
+type Album struct {
+	ID    int
+	Title string
+	Group string
+	// Нулевая дата - дата выхода неизвестна
+	ReleaseDate time.Time
+	Type        string
+	// Песни альбома по порядку: диск, затем номер трека
+	Tracks []AlbumTrack
+}
+
+// Песня на альбоме
+type AlbumTrack struct {
+	Song  string
+	Disc  int
+	Track int
+}
+
+// Альбом, на котором вышла песня, и ее место на нем
+type SongAlbum struct {
+	Title       string
+	ReleaseDate time.Time
+	Type        string
+	Disc        int
+	Track       int
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Кто и в рамках какого запроса изменяет песню
+type Actor struct {
+	Name      string
+	RequestID string
+}
+
+// Запись журнала аудита
+type AuditEntry struct {
+	ID        int
+	Song      string
+	Group     string
+	Operation string
+	Actor     string
+	RequestID string
+	// Состояние песни до и после изменения, nil - песни не было или она в корзине
+	Before    json.RawMessage
+	After     json.RawMessage
+	CreatedAt time.Time
+}
+
+// Фильтр журнала аудита, пустые поля не учитываются
+type AuditFilter struct {
+	Song  string
+	Group string
+	Actor string
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// Состояние песни в журнале аудита
+type auditImage struct {
+	Song        string `json:"song"`
+	Group       string `json:"group"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// Операции, после которых песни нет в списке: для них не пишется состояние "после"
+func auditHasAfter(operation string) bool {
+	return operation != RevisionDelete
+}
+
+// Операции, до которых песни не было в списке: для них не пишется состояние "до"
+func auditHasBefore(operation string) bool {
+	return operation != RevisionCreate && operation != RevisionRestore
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Позиция в списке песен для keyset-пагинации
+type Cursor struct {
+	// Ключ сортировки песни, на которой закончилась страница:
+	// значения полей сортировки и song_id
+	Values []string `json:"values,omitempty"`
+	ID     int      `json:"id"`
+	// Сортировка, для которой получен курсор
+	Sort string `json:"sort,omitempty"`
+	// Страница читается назад: песни до позиции, а не после
+	Backward bool `json:"backward,omitempty"`
+}
+
+// Кодирует позицию в непрозрачный токен
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Разбирает токен позиции
+func DecodeCursor(token string) (Cursor, error) {
+	cursor := Cursor{}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, errors.New("invalid cursor")
+	}
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || cursor.ID < 1 {
+		return cursor, errors.New("invalid cursor")
+	}
+	return cursor, nil
+}
+
+// Позиция сразу после или перед песней
+func newCursor(fields []SortField, song SongData, backward bool) Cursor {
+	return Cursor{Values: sortValues(fields, song), ID: song.ID, Sort: sortKey(fields), Backward: backward}
+}
+
+// Значения полей сортировки песни
+func sortValues(fields []SortField, song SongData) []string {
+	values := make([]string, 0, len(fields))
+	for _, field := range fields {
+		values = append(values, sortValue(field.Field, song))
+	}
+	return values
+}
+
+// Значение поля сортировки песни. Даты в формате ISO упорядочиваются так же, как строки,
+// названия приводятся к нижнему регистру, как lower() в sortColumns
+func sortValue(field FilterField, song SongData) string {
+	switch field {
+	case FieldSong:
+		return strings.ToLower(song.Song)
+	case FieldGroup:
+		return strings.ToLower(song.Group)
+	case FieldReleaseDate:
+		return song.ReleaseDate.Format("2006-01-02")
+	case FieldRating:
+		// Оценка от 0.00 до 5.00, при фиксированной ширине строки упорядочиваются как числа
+		return fmt.Sprintf("%.2f", song.Rating)
+	default:
+		return ""
+	}
+}
+
+// Страница списка песен
+type SongPage struct {
+	Songs []SongData
+	// Токены соседних страниц, пустые, если страницы нет
+	Next string
+	Prev string
+	// Страница получена keyset-пагинацией
+	Keyset bool
+}
+
+// Собирает страницу из песен, полученных по фильтру. В режиме keyset
+// хранилище возвращает на одну песню больше лимита, чтобы понять, есть ли следующая страница
+func NewSongPage(filter SongFilter, songs []SongData) SongPage {
+	page := SongPage{Songs: songs}
+	if filter.Limit == 0 {
+		return page
+	}
+	page.Keyset = true
+
+	hasMore := len(songs) > filter.Limit
+	if hasMore {
+		songs = songs[:filter.Limit]
+	}
+
+	// Назад песни читаются в обратном порядке
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	if backward {
+		songs = slices.Clone(songs)
+		slices.Reverse(songs)
+	}
+	page.Songs = songs
+
+	if len(songs) == 0 {
+		return page
+	}
+	first := songs[0]
+	last := songs[len(songs)-1]
+
+	if backward {
+		if hasMore {
+			page.Prev = newCursor(filter.Sort, first, true).Encode()
+		}
+		page.Next = newCursor(filter.Sort, last, false).Encode()
+	} else {
+		if hasMore {
+			page.Next = newCursor(filter.Sort, last, false).Encode()
+		}
+		if filter.Cursor != nil {
+			page.Prev = newCursor(filter.Sort, first, true).Encode()
+		}
+	}
+
+	return page
+}
@@ -2,25 +2,124 @@ package database
 
 import (
 	"database/sql"
-	"errors"
 	"fmt"
 	"music/tools"
-	"net/url"
-	"strconv"
 	"time"
 
 	_ "github.com/lib/pq"
 )
 
 type SongData struct {
+	// Внутренний id и публичный UUID, который не меняется за все время жизни песни
+	ID          int       `json:"-"`
+	PublicID    string    `json:"-"`
 	Song        string    `json:"song"`
 	Group       string    `json:"group"`
 	ReleaseDate time.Time `json:"releaseDate"`
 	Text        string    `json:"text"`
 	Link        string    `json:"link"`
+	// При обновлении - новое название и новая группа, пустые - без изменений
+	NewSong  string `json:"-"`
+	NewGroup string `json:"-"`
+	// Растет при каждом изменении песни
+	Version int `json:"-"`
+	// При обновлении - допустимые текущие версии, пусто - без проверки
+	ExpectedVersions []int `json:"-"`
+	// Средняя оценка и число оценок, 0 - оценок нет
+	Rating  float64 `json:"-"`
+	Ratings int     `json:"-"`
+	// Альбом песни при добавлении, nil - песня добавляется без альбома
+	Album *SongAlbum `json:"-"`
+	// Ссылки песни при добавлении, кроме Link
+	Links []Link `json:"-"`
 }
 
-var config *tools.Config = tools.GetConfig()
+// Хранилище песен
+type SongRepository interface {
+	// Возвращает id песни или -1, если песни нет. Песни из корзины не учитываются
+	Exists(song, group string) (int, error)
+	GetSong(id int) (SongData, error)
+	// Получает песню по публичному id. Песни из корзины не учитываются
+	SongByPublicID(publicID string) (SongData, error)
+	AddSong(data SongData, actor Actor) error
+	// Перемещает песню в корзину
+	DeleteSong(song, group string, actor Actor) error
+	// Перемещает песню в корзину по внутреннему id
+	DeleteSongByID(id int, actor Actor) error
+	// Возвращает новую версию песни. Если задан data.ID, песня ищется по нему
+	UpdateSong(data SongData, actor Actor) (int, error)
+	GetText(song, group string) (string, error)
+	ListSongs(filter SongFilter) ([]SongData, error)
+	SearchText(query SearchQuery) ([]SearchResult, error)
+	SimilarSongs(song, group string, limit int) ([]SongCandidate, error)
+	ListTrash() ([]TrashedSong, error)
+	RestoreSong(song, group string, actor Actor) error
+	PurgeTrash(before time.Time) (int64, error)
+	ListRevisions(song, group string) ([]SongRevision, error)
+	GetRevision(song, group string, revision int) (SongRevision, error)
+	RevertSong(song, group string, revision int, actor Actor) error
+	ListAudit(filter AuditFilter) ([]AuditEntry, error)
+	// Пустые group и album не ограничивают выборку
+	ListAlbums(group, album string) ([]Album, error)
+	AddAlbum(album Album) error
+	// Обновляет дату выхода и тип альбома, пустые поля остаются без изменений
+	UpdateAlbum(album Album) error
+	DeleteAlbum(album, group string) error
+	// Добавляет песню группы на ее альбом
+	AddTrack(album, group, song string, disc, track int) error
+	RemoveTrack(album, group, song string) error
+	AddMembership(membership Membership) error
+	// Нулевая дата from удаляет все периоды участия в этой роли
+	DeleteMembership(person, group, role string, from time.Time) error
+	// Участники группы в указанный день
+	ListLineup(group string, date time.Time) ([]Membership, error)
+	// Все периоды участия человека в группах
+	ListMemberships(person string) ([]Membership, error)
+	// Метки песни по видам
+	SongLabels(song, group string) (map[LabelKind][]string, error)
+	// Меняют метки всех видов сразу, в Postgres - в одной транзакции
+	AttachLabels(song, group string, labels map[LabelKind][]string) error
+	DetachLabels(song, group string, labels map[LabelKind][]string) error
+	// Добавляет метки всем песням, подходящим под условия фильтра, и возвращает их число
+	LabelSongs(filter SongFilter, labels map[LabelKind][]string) (int64, error)
+	ListLabels(kind LabelKind) ([]Label, error)
+	// Получает плейлисты с числом песен, без самих песен
+	ListPlaylists() ([]Playlist, error)
+	// Получает плейлист с песнями по порядку
+	GetPlaylist(name string) (Playlist, error)
+	// Создает пустой плейлист
+	AddPlaylist(name string) error
+	// Переименовывает плейлист
+	RenamePlaylist(name, newName string) error
+	// Удаляет плейлист. Песни остаются
+	DeletePlaylist(name string) error
+	// Ставит песню в плейлист на позицию position, 0 - в конец. Возвращает позицию песни
+	AddEntry(playlist, song, group string, position int) (int, error)
+	// Убирает песню с позиции плейлиста, следующие песни сдвигаются вверх
+	RemoveEntry(playlist string, position int) error
+	// Переставляет песню с позиции from на позицию to
+	MoveEntry(playlist string, from, to int) error
+	// Регистрирует пользователя
+	AddUser(name string) error
+	// Получает пользователя с числом избранных и оцененных песен
+	GetUser(name string) (User, error)
+	// Добавляет песню в избранное пользователя или убирает из него
+	SetFavorite(user, song, group string, favorite bool) error
+	// Ставит песне оценку пользователя, 0 - убирает оценку. Возвращает среднюю оценку и число оценок песни
+	RateSong(user, song, group string, rating int) (float64, int, error)
+	// Записывает прослушивание песни пользователем
+	AddPlay(user, song, group string, playedAt time.Time, duration int) error
+	// Получает последние прослушивания пользователя, новые первыми
+	ListPlays(user string, limit int) ([]Play, error)
+	// Строит чарт песен и групп за период по суточным суммам прослушиваний, с местами за период previous
+	Chart(period, previous DateRange, limit int) (Chart, error)
+	// Получает ссылки песни по порядку добавления
+	SongLinks(song, group string) ([]Link, error)
+	// Добавляет песне ссылку, URL уже приведен к каноническому виду
+	AddLink(song, group string, link Link) error
+	// Убирает ссылку песни по каноническому URL
+	DeleteLink(song, group, url string) error
+}
 
 // Открывает соединение с БД
 func OpenConnection(config *tools.Config) (*sql.DB, error) {
@@ -36,311 +135,27 @@ func OpenConnection(config *tools.Config) (*sql.DB, error) {
 	return db, nil
 }
 
-// Проверяет существование песни в БД
-func Exists(song, group string) (int, error) {
-	db, err := OpenConnection(config)
-	if err != nil {
-		return -1, err
-	}
-	defer db.Close()
-	defer tools.Logger.Info("Database connection closed")
-
-	userID := -1
-	statement := fmt.Sprintf(`SELECT s.song_id FROM "Song" s JOIN "Group" g ON s.group_id = g.group_id WHERE s.name = '%s' AND g.name = '%s'`, song, group)
-	rows, err := db.Query(statement)
-	if err != nil {
-		tools.Logger.Error("Failed to execute SELECT query: ", err)
-		return -1, err
-	}
-
-	for rows.Next() {
-		var id int
-		err = rows.Scan(&id)
-		if err != nil {
-			tools.Logger.Error("Failed to scan from sql.Rows: ", err)
-			return -1, err
-		}
-		userID = id
-	}
-	defer rows.Close()
-	return userID, nil
-}
-
-// Получает данные о песни по id
-func GetSong(id int) (SongData, error) {
-	data := SongData{}
-	db, err := OpenConnection(config)
-	if err != nil {
-		return data, err
-	}
-	defer db.Close()
-	defer tools.Logger.Info("Database connection closed")
-
-	statement := fmt.Sprintf(`SELECT s.name, g.name, "release_date", "text", "link" FROM "Song" s JOIN "Group" g on s.group_id = g.group_id WHERE song_id = %d`, id)
-	rows, err := db.Query(statement)
-	if err != nil {
-		tools.Logger.Error("Failed to execute SELECT query: ", err)
-		return data, err
-	}
-
-	for rows.Next() {
-		var dateString string
-		err = rows.Scan(&data.Song, &data.Group, &dateString, &data.Text, &data.Link)
-		if err != nil {
-			tools.Logger.Error("Failed to scan from sql.Rows: ", err)
-			return data, err
-		}
-		data.ReleaseDate, err = time.Parse("2006-01-02T15:04:05Z07:00", dateString)
-		if err != nil {
-			tools.Logger.Error("Failed to parse time: ", err)
-			return data, err
-		}
-
-	}
-	defer rows.Close()
-	return data, nil
-}
-
-// Конструирует запрос на основе фильтра
-func BuildListQuery(params url.Values) string {
-	query := `SELECT s.name song, g.name "group", "release_date", "text", "link" FROM "Song" s JOIN "Group" g on s.group_id = g.group_id `
-	emptyParams := true
-
-	for param, list := range params {
-		if len(list) > 0 && param != "page" && param != "onpage" {
-			query += " WHERE "
-			break
-		}
-	}
-
-	for param, list := range params {
-		if param != "page" && param != "onpage" && len(list) != 0 {
-			if param == "releasedate" {
-				param = "release_date"
-			}
-			emptyParams = false
-			if param == "song" {
-				query += `s.name IN ('`
-			} else if param == "group" {
-				query += `g.name IN ('`
-			} else {
-				query += `"` + param + `" IN ('`
-			}
-			for _, value := range list {
-				if param == "release_date" {
-					parsedDate, _ := time.Parse("02.01.2006", value)
-					value = parsedDate.Format("2006-01-02")
-				}
-				query += value + "', '"
-			}
-			query = query[:len(query)-3]
-			query += ") AND "
-		}
-	}
-
-	if !emptyParams {
-		query = query[:len(query)-4]
-	}
-
-	// Дефолтное значение числа песен на странице
-	onpage := 5
-
-	if len(params["onpage"]) != 0 {
-		onpage, _ = strconv.Atoi(params["onpage"][0])
-		query += fmt.Sprintf("LIMIT %d ", onpage)
-	}
-	if len(params["page"]) != 0 {
-		page, _ := strconv.Atoi(params["page"][0])
-		query += fmt.Sprintf("OFFSET %d", (page-1)*onpage)
-	}
-
-	return query
-}
-
-// Добавляет новую песню
-func AddSong(data SongData) error {
-	db, err := OpenConnection(config)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-	defer tools.Logger.Info("Database connection closed")
-
-	userID, err := Exists(data.Song, data.Group)
-	if err != nil {
-		return err
-	}
-	if userID != -1 {
-		tools.Logger.Info(fmt.Sprintf("Attempt to add an existing song: '%s' by '%s'\n", data.Song, data.Group))
-		err = errors.New("song already exists")
-		return err
-	}
-
-	statement1 := `
-		INSERT INTO "Group" (name)
-		SELECT CAST($1 AS VARCHAR)
-		WHERE NOT EXISTS (
-    		SELECT 1 
-    		FROM "Group" 
-    		WHERE name = $1
-		);`
-
-	_, err = db.Exec(statement1, data.Group)
-	if err != nil {
-		tools.Logger.Error("Failed to execute INSERT query 1: ", err)
-		return err
-	}
-
-	statement2 := `INSERT INTO "Song" ("name", "release_date", "text", "link", "group_id")
-		VALUES	($2, $3, $4, $5, 
-			(
-			SELECT group_id
-			FROM "Group"
-			WHERE name = $1
-			)
-				);`
-
-	_, err = db.Exec(statement2, data.Group, data.Song, data.ReleaseDate, data.Text, data.Link)
-	if err != nil {
-		tools.Logger.Error("Failed to execute INSERT query 2: ", err)
-		return err
-	}
-	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' added successfully\n", data.Song, data.Group))
-	return nil
-}
-
-// Удаляет песню
-func DeleteSong(song, group string) error {
-	db, err := OpenConnection(config)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-	defer tools.Logger.Info("Database connection closed")
-
-	id, err := Exists(song, group)
-	if err != nil {
-		return err
-	}
-	if id == -1 {
-		tools.Logger.Info(fmt.Sprintf("Attempt to delete a non-existent song: '%s' by '%s'\n", song, group))
-		err = errors.New("song does not exist")
-		return err
-	}
-
-	statement := `delete from "Song" where song_id = $1`
-	_, err = db.Exec(statement, id)
-	if err != nil {
-		tools.Logger.Error("Failed to execute DELETE query: ", err)
-		return err
-	}
-
-	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' deleted successfully\n", song, group))
-	return nil
-}
-
-// Обновляет информацию о песне
-func UpdateSong(data SongData) error {
+// Открывает общий пул соединений с БД с настройками из конфигурации
+func OpenPool(config *tools.Config) (*sql.DB, error) {
 	db, err := OpenConnection(config)
 	if err != nil {
-		return err
-	}
-	defer db.Close()
-	defer tools.Logger.Info("Database connection closed")
-
-	id, err := Exists(data.Song, data.Group)
-	if err != nil {
-		return err
-	}
-	if id == -1 {
-		tools.Logger.Info(fmt.Sprintf("Attempt to update a non-existent song: '%s' by '%s'\n", data.Song, data.Group))
-		err = errors.New("song does not exist")
-		return err
-	}
-
-	oldData, err := GetSong(id)
-	if err != nil {
-		return err
-	}
-
-	if data.ReleaseDate.IsZero() {
-		data.ReleaseDate = oldData.ReleaseDate
-	}
-	if data.Text == "" {
-		data.Text = oldData.Text
-	}
-	if data.Link == "" {
-		data.Link = oldData.Link
-	}
-
-	statement := `update "Song" set "release_date" = $1, "text" = $2, "link" = $3 where "song_id" = $4`
-	_, err = db.Exec(statement, data.ReleaseDate, data.Text, data.Link, id)
-	if err != nil {
-		tools.Logger.Error("Failed to execute UPDATE query: ", err)
-		return err
-	}
-
-	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' updated successfully\n", data.Song, data.Group))
-	return nil
-}
-
-// Получает текст песни
-func GetText(song, group string) (string, error) {
-	id, err := Exists(song, group)
-	if err != nil {
-		return "", err
-	}
-	if id == -1 {
-		tools.Logger.Info(fmt.Sprintf("Attempt to get text of non-existent song: '%s' by '%s'\n", song, group))
-		err = errors.New("song does not exist")
-		return "", err
-	}
-
-	data, err := GetSong(id)
-	if err != nil {
-		return "", err
-	}
-
-	text := data.Text
-
-	tools.Logger.Info(fmt.Sprintf("Got text of '%s' by '%s' successfully\n", song, group))
-	return text, nil
-}
-
-// Получает список песен
-func ListSongs(params url.Values) ([]SongData, error) {
-	data := []SongData{}
-	db, err := OpenConnection(config)
-	if err != nil {
-		return data, err
+		return nil, err
 	}
-	defer db.Close()
-	defer tools.Logger.Info("Database connection closed")
 
-	statment := BuildListQuery(params)
-	rows, err := db.Query(statment)
-	if err != nil {
-		tools.Logger.Error("Failed to execute SELECT query ", err)
-		return data, err
-	}
+	db.SetMaxOpenConns(config.DBMaxOpenConns)
+	db.SetMaxIdleConns(config.DBMaxIdleConns)
+	db.SetConnMaxLifetime(config.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.DBConnMaxIdleTime)
 
-	for rows.Next() {
-		temp := SongData{}
-		dateString := ""
-		err = rows.Scan(&temp.Song, &temp.Group, &dateString, &temp.Text, &temp.Link)
-		if err != nil {
-			tools.Logger.Error("Failed to scan sql.Rows: ", err)
-			return data, err
-		}
-		temp.ReleaseDate, err = time.Parse("2006-01-02T15:04:05Z07:00", dateString)
+	if config.DBPingOnStart {
+		err = db.Ping()
 		if err != nil {
-			tools.Logger.Error("Failed to parse time: ", err)
-			return data, err
+			tools.Logger.Error("Failed to ping the database: ", err)
+			db.Close()
+			return nil, err
 		}
-		data = append(data, temp)
-
 	}
 
-	tools.Logger.Info("Got list of songs successfully")
-	return data, nil
+	tools.Logger.Info(fmt.Sprintf("Database pool opened: max open %d, max idle %d", config.DBMaxOpenConns, config.DBMaxIdleConns))
+	return db, nil
 }
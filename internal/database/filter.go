@@ -0,0 +1,651 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Поле, по которому фильтруется список песен
+type FilterField string
+
+const (
+	FieldSong        FilterField = "song"
+	FieldGroup       FilterField = "group"
+	FieldReleaseDate FilterField = "releasedate"
+	FieldText        FilterField = "text"
+	FieldLink        FilterField = "link"
+	// Название любого из альбомов песни
+	FieldAlbum FilterField = "album"
+	// Имя участника группы на дату выхода песни
+	FieldArtist FilterField = "artist"
+	FieldGenre  FilterField = "genre"
+	FieldTag    FilterField = "tag"
+	// Сервис любой из ссылок песни
+	FieldProvider FilterField = "provider"
+	// Средняя оценка, только для сортировки
+	FieldRating FilterField = "rating"
+)
+
+// Порядок полей фиксирован, чтобы номера плейсхолдеров не зависели от обхода map
+var filterFields = []FilterField{FieldSong, FieldGroup, FieldReleaseDate, FieldText, FieldLink, FieldAlbum, FieldArtist, FieldGenre, FieldTag, FieldProvider}
+
+// Белый список колонок для фильтрации
+var filterColumns = map[FilterField]string{
+	FieldSong:        `s.name`,
+	FieldGroup:       `g.name`,
+	FieldReleaseDate: `s.release_date`,
+	FieldText:        `s."text"`,
+	FieldLink:        `s."link"`,
+	FieldAlbum:       `a.title`,
+	FieldArtist:      `p.name`,
+	FieldGenre:       `ge.name`,
+	FieldTag:         `tg.name`,
+	FieldProvider:    `sl.provider`,
+}
+
+// Поля из связанных таблиц: условие на них проверяется подзапросом EXISTS
+var relatedQueries = map[FilterField]string{
+	FieldAlbum: `SELECT 1 FROM "AlbumTrack" t JOIN "Album" a ON a.album_id = t.album_id WHERE t.song_id = s.song_id`,
+	FieldArtist: `SELECT 1 FROM "Membership" m JOIN "Person" p ON p.person_id = m.person_id
+		WHERE m.group_id = s.group_id
+			AND (m.active_from IS NULL OR m.active_from <= s.release_date)
+			AND (m.active_to IS NULL OR m.active_to >= s.release_date)`,
+	FieldGenre:    `SELECT 1 FROM "SongGenre" sg JOIN "Genre" ge ON ge.genre_id = sg.genre_id WHERE sg.song_id = s.song_id`,
+	FieldTag:      `SELECT 1 FROM "SongTag" st JOIN "Tag" tg ON tg.tag_id = st.tag_id WHERE st.song_id = s.song_id`,
+	FieldProvider: `SELECT 1 FROM "SongLink" sl WHERE sl.song_id = s.song_id`,
+}
+
+// Оператор сравнения в условии фильтра
+type FilterOperator string
+
+const (
+	OperatorIn FilterOperator = "in"
+	// Значение не меньше единственного значения условия
+	OperatorGTE FilterOperator = "gte"
+	// Значение не больше единственного значения условия
+	OperatorLTE FilterOperator = "lte"
+	// Значение попадает хотя бы в один из интервалов DateRange
+	OperatorRange FilterOperator = "range"
+	// Текстовые операторы, буква i - без учета регистра
+	OperatorIEq       FilterOperator = "ieq"
+	OperatorContains  FilterOperator = "contains"
+	OperatorIContains FilterOperator = "icontains"
+	OperatorPrefix    FilterOperator = "prefix"
+	OperatorIPrefix   FilterOperator = "iprefix"
+	// Для полей из связанных таблиц: у песни есть все значения условия, а не хотя бы одно
+	OperatorAll FilterOperator = "all"
+	// Нечеткое совпадение названия песни или группы по триграммам, как в подсказках для 404
+	OperatorSimilar FilterOperator = "similar"
+)
+
+// Операторы, которые можно указать в имени параметра: song[contains]=road
+var paramOperators = map[string]FilterOperator{
+	"eq":        OperatorIn,
+	"ieq":       OperatorIEq,
+	"contains":  OperatorContains,
+	"icontains": OperatorIContains,
+	"prefix":    OperatorPrefix,
+	"iprefix":   OperatorIPrefix,
+	"all":       OperatorAll,
+	"similar":   OperatorSimilar,
+}
+
+// Порядок операторов фиксирован по той же причине, что и порядок полей
+var paramOperatorNames = []string{"eq", "ieq", "contains", "icontains", "prefix", "iprefix", "all", "similar"}
+
+// Поля, для которых доступны текстовые операторы
+var textFields = map[FilterField]bool{
+	FieldSong:   true,
+	FieldGroup:  true,
+	FieldText:   true,
+	FieldLink:   true,
+	FieldAlbum:  true,
+	FieldArtist: true,
+	FieldGenre:  true,
+	FieldTag:    true,
+}
+
+// Разбирает имя параметра фильтра вида "song" или "song[contains]"
+func ParseFilterParam(param string) (FilterField, FilterOperator, error) {
+	name, suffix, hasOperator := strings.Cut(param, "[")
+	field := FilterField(name)
+	if _, ok := filterColumns[field]; !ok {
+		return "", "", fmt.Errorf("unknown filter field '%s'", name)
+	}
+	if !hasOperator {
+		return field, OperatorIn, nil
+	}
+
+	operatorName, ok := strings.CutSuffix(suffix, "]")
+	if !ok || !textFields[field] {
+		return "", "", fmt.Errorf("operators are not supported for '%s'", param)
+	}
+	operator, ok := paramOperators[operatorName]
+	if !ok {
+		return "", "", fmt.Errorf("unknown filter operator '%s'", operatorName)
+	}
+	if _, related := relatedQueries[field]; operator == OperatorAll && !related {
+		return "", "", fmt.Errorf("operators are not supported for '%s'", param)
+	}
+	if operator == OperatorSimilar && field != FieldSong && field != FieldGroup {
+		return "", "", fmt.Errorf("operators are not supported for '%s'", param)
+	}
+	return field, operator, nil
+}
+
+// Полуинтервал дат [From, To)
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// Параметры, задающие диапазон дат выхода песни
+var dateRangeParams = map[string]bool{
+	"releasedfrom": true,
+	"releasedto":   true,
+	"year":         true,
+	"decade":       true,
+}
+
+// Условие фильтра: значение поля должно удовлетворять оператору хотя бы для одного из значений
+type FilterCondition struct {
+	Field    FilterField
+	Operator FilterOperator
+	// string для текстовых полей, time.Time для дат, DateRange для OperatorRange
+	Values []any
+}
+
+// Фильтр списка песен, условия объединяются через AND
+type SongFilter struct {
+	Conditions []FilterCondition
+	// Номер страницы, 0 - без смещения
+	Page int
+	// Число песен на странице, 0 - без ограничения
+	OnPage int
+	// Keyset-пагинация: число песен на странице и позиция, с которой продолжить.
+	// Limit != 0 включает режим keyset вместо page/onpage
+	Limit  int
+	Cursor *Cursor
+	// Поля сортировки, при равенстве песни упорядочиваются по song_id
+	Sort []SortField
+	// Только избранные песни пользователя, пусто - все песни
+	FavoriteOf string
+}
+
+// Дефолтное значение числа песен на странице
+const defaultOnPage = 5
+
+// Дефолтное число песен на странице в режиме keyset
+const DefaultLimit = 20
+
+// Параметры пагинации и сортировки, не являющиеся полями фильтра
+var listParams = map[string]bool{
+	"page":   true,
+	"onpage": true,
+	"limit":  true,
+	"cursor": true,
+	"sort":   true,
+}
+
+// Поле сортировки
+type SortField struct {
+	Field      FilterField
+	Descending bool
+}
+
+// Белый список колонок для сортировки. Названия сравниваются в нижнем регистре по кодам
+// символов (COLLATE "C"), как в MemoryRepository: порядок и курсоры не зависят от collation базы
+var sortColumns = map[FilterField]string{
+	FieldSong:        `lower(s.name) COLLATE "C"`,
+	FieldGroup:       `lower(g.name) COLLATE "C"`,
+	FieldReleaseDate: `s.release_date`,
+	FieldRating:      `s.rating_avg`,
+}
+
+// Разбирает список полей сортировки вида "-releasedate", "group"
+func ParseSort(values []string) ([]SortField, error) {
+	fields := []SortField{}
+	seen := map[FilterField]bool{}
+
+	for _, value := range values {
+		field := SortField{Field: FilterField(strings.TrimPrefix(value, "-")), Descending: strings.HasPrefix(value, "-")}
+		if _, ok := sortColumns[field.Field]; !ok {
+			return fields, fmt.Errorf("invalid sort field '%s'", value)
+		}
+		if seen[field.Field] {
+			return fields, fmt.Errorf("duplicate sort field '%s'", field.Field)
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// Строковое представление сортировки, сохраняется в курсоре
+func sortKey(fields []SortField) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field.Descending {
+			parts = append(parts, "-"+string(field.Field))
+		} else {
+			parts = append(parts, string(field.Field))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Строит фильтр из проверенных параметров запроса
+func NewSongFilter(params url.Values) (SongFilter, error) {
+	filter := SongFilter{}
+
+	for param := range params {
+		if listParams[param] || dateRangeParams[param] {
+			continue
+		}
+		if _, _, err := ParseFilterParam(param); err != nil {
+			return filter, err
+		}
+	}
+
+	for _, field := range filterFields {
+		for _, operatorName := range append([]string{""}, paramOperatorNames...) {
+			param := string(field)
+			operator := OperatorIn
+			if operatorName != "" {
+				param += "[" + operatorName + "]"
+				operator = paramOperators[operatorName]
+			}
+
+			list := params[param]
+			if len(list) == 0 {
+				continue
+			}
+
+			condition := FilterCondition{Field: field, Operator: operator}
+			for _, value := range list {
+				if field == FieldReleaseDate {
+					date, err := time.Parse("2.1.2006", value)
+					if err != nil {
+						return filter, errors.New("incorrect date format")
+					}
+					condition.Values = append(condition.Values, date)
+				} else if field == FieldGenre || field == FieldTag {
+					condition.Values = append(condition.Values, NormalizeLabel(value))
+				} else if field == FieldProvider {
+					provider, err := ParseLinkProvider(value)
+					if err != nil {
+						return filter, fmt.Errorf("unknown link provider '%s'", value)
+					}
+					condition.Values = append(condition.Values, string(provider))
+				} else {
+					condition.Values = append(condition.Values, value)
+				}
+			}
+			filter.Conditions = append(filter.Conditions, condition)
+		}
+	}
+
+	dateConditions, err := dateRangeConditions(params)
+	if err != nil {
+		return filter, err
+	}
+	filter.Conditions = append(filter.Conditions, dateConditions...)
+
+	if len(params["onpage"]) != 0 {
+		onpage, err := strconv.Atoi(params["onpage"][0])
+		if err != nil || onpage < 1 {
+			return filter, errors.New("onpage is not a number")
+		}
+		filter.OnPage = onpage
+	}
+	if len(params["page"]) != 0 {
+		page, err := strconv.Atoi(params["page"][0])
+		if err != nil || page < 1 {
+			return filter, errors.New("page is not a number")
+		}
+		filter.Page = page
+	}
+
+	sort, err := ParseSort(params["sort"])
+	if err != nil {
+		return filter, err
+	}
+	filter.Sort = sort
+
+	if len(params["limit"]) != 0 {
+		limit, err := strconv.Atoi(params["limit"][0])
+		if err != nil || limit < 1 {
+			return filter, errors.New("limit is not a number")
+		}
+		filter.Limit = limit
+	}
+	if len(params["cursor"]) != 0 {
+		cursor, err := DecodeCursor(params["cursor"][0])
+		if err != nil {
+			return filter, err
+		}
+		// Курсор действителен только для той сортировки, с которой он получен
+		if cursor.Sort != sortKey(filter.Sort) || len(cursor.Values) != len(filter.Sort) {
+			return filter, errors.New("invalid cursor")
+		}
+		filter.Cursor = &cursor
+		if filter.Limit == 0 {
+			filter.Limit = DefaultLimit
+		}
+	}
+
+	return filter, nil
+}
+
+// Строит условия на дату выхода из параметров releasedfrom, releasedto, year и decade
+func dateRangeConditions(params url.Values) ([]FilterCondition, error) {
+	conditions := []FilterCondition{}
+
+	if len(params["releasedfrom"]) != 0 {
+		date, err := time.Parse("2.1.2006", params["releasedfrom"][0])
+		if err != nil {
+			return conditions, errors.New("incorrect date format")
+		}
+		conditions = append(conditions, FilterCondition{Field: FieldReleaseDate, Operator: OperatorGTE, Values: []any{date}})
+	}
+
+	if len(params["releasedto"]) != 0 {
+		date, err := time.Parse("2.1.2006", params["releasedto"][0])
+		if err != nil {
+			return conditions, errors.New("incorrect date format")
+		}
+		conditions = append(conditions, FilterCondition{Field: FieldReleaseDate, Operator: OperatorLTE, Values: []any{date}})
+	}
+
+	if len(params["year"]) != 0 {
+		condition := FilterCondition{Field: FieldReleaseDate, Operator: OperatorRange}
+		for _, value := range params["year"] {
+			year, err := ParseYear(value)
+			if err != nil {
+				return conditions, err
+			}
+			condition.Values = append(condition.Values, DateRange{
+				From: time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC),
+			})
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if len(params["decade"]) != 0 {
+		condition := FilterCondition{Field: FieldReleaseDate, Operator: OperatorRange}
+		for _, value := range params["decade"] {
+			decade, err := ParseDecade(value)
+			if err != nil {
+				return conditions, err
+			}
+			condition.Values = append(condition.Values, DateRange{
+				From: time.Date(decade, time.January, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(decade+10, time.January, 1, 0, 0, 0, 0, time.UTC),
+			})
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return conditions, nil
+}
+
+// Разбирает год, например "1994"
+func ParseYear(value string) (int, error) {
+	year, err := strconv.Atoi(value)
+	if err != nil || year < 1 || year > 9999 {
+		return 0, errors.New("incorrect year")
+	}
+	return year, nil
+}
+
+// Разбирает десятилетие, например "1990" или "1990s"
+func ParseDecade(value string) (int, error) {
+	decade, err := strconv.Atoi(strings.TrimSuffix(value, "s"))
+	if err != nil || decade < 0 || decade > 9990 || decade%10 != 0 {
+		return 0, errors.New("incorrect decade")
+	}
+	return decade, nil
+}
+
+// Смещение первой песни страницы
+func (f SongFilter) offset() int {
+	if f.Page == 0 {
+		return 0
+	}
+	onpage := f.OnPage
+	if onpage == 0 {
+		onpage = defaultOnPage
+	}
+	return (f.Page - 1) * onpage
+}
+
+// Собирает аргументы запроса и выдает для них плейсхолдеры $n
+type queryArgs struct {
+	values []any
+}
+
+func (a *queryArgs) add(value any) string {
+	a.values = append(a.values, value)
+	return fmt.Sprintf("$%d", len(a.values))
+}
+
+// Компилирует условие в SQL
+func (c FilterCondition) toSQL(args *queryArgs) (string, error) {
+	if c.Operator == OperatorAll {
+		return c.allSQL(args)
+	}
+
+	sql, err := c.columnSQL(args)
+	related, ok := relatedQueries[c.Field]
+	if err != nil || !ok {
+		return sql, err
+	}
+
+	// Связанных строк может быть несколько, песня подходит, если подходит хотя бы одна
+	return fmt.Sprintf(`EXISTS (%s AND %s)`, related, sql), nil
+}
+
+// Компилирует условие OperatorAll: отдельный EXISTS на каждое значение
+func (c FilterCondition) allSQL(args *queryArgs) (string, error) {
+	related, ok := relatedQueries[c.Field]
+	if !ok {
+		return "", fmt.Errorf("operator '%s' is not supported for '%s'", c.Operator, c.Field)
+	}
+
+	parts := make([]string, 0, len(c.Values))
+	for _, value := range c.Values {
+		parts = append(parts, fmt.Sprintf(`EXISTS (%s AND %s = %s)`, related, filterColumns[c.Field], args.add(value)))
+	}
+	return "(" + strings.Join(parts, " AND ") + ")", nil
+}
+
+// Компилирует условие на значение колонки в SQL
+func (c FilterCondition) columnSQL(args *queryArgs) (string, error) {
+	column, ok := filterColumns[c.Field]
+	if !ok {
+		return "", fmt.Errorf("unknown filter field '%s'", c.Field)
+	}
+
+	switch c.Operator {
+	case OperatorIn:
+		placeholders := make([]string, 0, len(c.Values))
+		for _, value := range c.Values {
+			if date, ok := value.(time.Time); ok {
+				value = date.Format("2006-01-02")
+			}
+			placeholders = append(placeholders, args.add(value))
+		}
+		return fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")), nil
+	case OperatorGTE, OperatorLTE:
+		if len(c.Values) != 1 {
+			return "", fmt.Errorf("operator '%s' requires 1 value", c.Operator)
+		}
+		value := c.Values[0]
+		if date, ok := value.(time.Time); ok {
+			value = date.Format("2006-01-02")
+		}
+		operator := ">="
+		if c.Operator == OperatorLTE {
+			operator = "<="
+		}
+		return fmt.Sprintf("%s %s %s", column, operator, args.add(value)), nil
+	case OperatorRange:
+		ranges := make([]string, 0, len(c.Values))
+		for _, value := range c.Values {
+			dates, ok := value.(DateRange)
+			if !ok {
+				return "", fmt.Errorf("operator '%s' requires date ranges", c.Operator)
+			}
+			from := args.add(dates.From.Format("2006-01-02"))
+			to := args.add(dates.To.Format("2006-01-02"))
+			ranges = append(ranges, fmt.Sprintf("(%s >= %s AND %s < %s)", column, from, column, to))
+		}
+		return "(" + strings.Join(ranges, " OR ") + ")", nil
+	case OperatorIEq, OperatorContains, OperatorIContains, OperatorPrefix, OperatorIPrefix, OperatorSimilar:
+		alternatives := make([]string, 0, len(c.Values))
+		for _, value := range c.Values {
+			text, ok := value.(string)
+			if !ok {
+				return "", fmt.Errorf("operator '%s' requires text values", c.Operator)
+			}
+			switch c.Operator {
+			case OperatorIEq:
+				alternatives = append(alternatives, fmt.Sprintf("lower(%s) = lower(%s)", column, args.add(text)))
+			case OperatorContains:
+				alternatives = append(alternatives, fmt.Sprintf(`%s LIKE %s ESCAPE '\'`, column, args.add("%"+escapeLike(text)+"%")))
+			case OperatorIContains:
+				alternatives = append(alternatives, fmt.Sprintf(`%s ILIKE %s ESCAPE '\'`, column, args.add("%"+escapeLike(text)+"%")))
+			case OperatorPrefix:
+				alternatives = append(alternatives, fmt.Sprintf(`%s LIKE %s ESCAPE '\'`, column, args.add(escapeLike(text)+"%")))
+			case OperatorIPrefix:
+				alternatives = append(alternatives, fmt.Sprintf(`%s ILIKE %s ESCAPE '\'`, column, args.add(escapeLike(text)+"%")))
+			case OperatorSimilar:
+				// Оператор % использует триграммные индексы, его порог pg_trgm.similarity_threshold
+				// по умолчанию равен similarityThreshold
+				alternatives = append(alternatives, fmt.Sprintf("%s %% %s", column, args.add(text)))
+			}
+		}
+		return "(" + strings.Join(alternatives, " OR ") + ")", nil
+	default:
+		return "", fmt.Errorf("unknown filter operator '%s'", c.Operator)
+	}
+}
+
+// Экранирует спецсимволы LIKE, чтобы значение искалось буквально
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// Компилирует условия фильтра без пагинации
+func (f SongFilter) whereSQL(args *queryArgs) ([]string, error) {
+	// Песни из корзины в список не попадают
+	conditions := []string{"s.deleted_at IS NULL"}
+	for _, condition := range f.Conditions {
+		sql, err := condition.toSQL(args)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, sql)
+	}
+	if f.FavoriteOf != "" {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM "Favorite" f JOIN "User" u ON u.user_id = f.user_id WHERE f.song_id = s.song_id AND u.name = `+args.add(f.FavoriteOf)+`)`)
+	}
+	return conditions, nil
+}
+
+// Конструирует параметризованный запрос на основе фильтра
+func BuildListQuery(filter SongFilter) (string, []any, error) {
+	query := `SELECT s.song_id, s.name song, g.name "group", "release_date", "text", "link", s.version, s.rating_avg, s.rating_count, s.public_id FROM "Song" s JOIN "Group" g on s.group_id = g.group_id`
+	args := &queryArgs{}
+
+	conditions, err := filter.whereSQL(args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Keyset-пагинация: песни после или перед позицией курсора
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	if filter.Cursor != nil {
+		conditions = append(conditions, keysetCondition(filter.Sort, *filter.Cursor, args))
+	}
+
+	query += " WHERE " + strings.Join(conditions, " AND ")
+	query += " ORDER BY " + orderBy(filter.Sort, backward)
+
+	if filter.Limit != 0 {
+		// Лишняя песня показывает, есть ли следующая страница
+		query += " LIMIT " + args.add(filter.Limit+1)
+		return query, args.values, nil
+	}
+
+	if filter.OnPage != 0 {
+		query += " LIMIT " + args.add(filter.OnPage)
+	}
+	if filter.Page != 0 {
+		query += " OFFSET " + args.add(filter.offset())
+	}
+
+	return query, args.values, nil
+}
+
+// Колонка ключа сортировки и ее направление
+type sortColumn struct {
+	column     string
+	descending bool
+}
+
+// Ключ сортировки: поля сортировки и song_id для однозначности
+func sortColumnsOf(fields []SortField) []sortColumn {
+	columns := make([]sortColumn, 0, len(fields)+1)
+	for _, field := range fields {
+		columns = append(columns, sortColumn{column: sortColumns[field.Field], descending: field.Descending})
+	}
+	return append(columns, sortColumn{column: "s.song_id"})
+}
+
+// Конструирует ORDER BY, при чтении назад направления меняются на обратные
+func orderBy(fields []SortField, backward bool) string {
+	parts := []string{}
+	for _, key := range sortColumnsOf(fields) {
+		if key.descending != backward {
+			parts = append(parts, key.column+" DESC")
+		} else {
+			parts = append(parts, key.column+" ASC")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Конструирует условие "после позиции курсора" для ключа из нескольких колонок:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., где знак зависит от направления колонки
+func keysetCondition(fields []SortField, cursor Cursor, args *queryArgs) string {
+	columns := sortColumnsOf(fields)
+
+	placeholders := make([]string, 0, len(columns))
+	for _, value := range cursor.Values {
+		placeholders = append(placeholders, args.add(value))
+	}
+	placeholders = append(placeholders, args.add(cursor.ID))
+
+	alternatives := make([]string, 0, len(columns))
+	for i, key := range columns {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, columns[j].column+" = "+placeholders[j])
+		}
+		operator := ">"
+		if key.descending != cursor.Backward {
+			operator = "<"
+		}
+		parts = append(parts, key.column+" "+operator+" "+placeholders[i])
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")"
+}
@@ -0,0 +1,34 @@
+package database
+
+import "strings"
+
+// Вид метки песни: жанр или произвольный тег
+type LabelKind string
+
+const (
+	LabelGenre LabelKind = "genre"
+	LabelTag   LabelKind = "tag"
+)
+
+// Метка и число неудаленных песен с ней
+type Label struct {
+	Name  string
+	Songs int
+}
+
+// Таблицы меток одного вида
+type labelTables struct {
+	table  string
+	link   string
+	column string
+}
+
+var labelTablesOf = map[LabelKind]labelTables{
+	LabelGenre: {table: `"Genre"`, link: `"SongGenre"`, column: "genre_id"},
+	LabelTag:   {table: `"Tag"`, link: `"SongTag"`, column: "tag_id"},
+}
+
+// Приводит название метки к виду, в котором оно хранится: без пробелов по краям и в нижнем регистре
+func NormalizeLabel(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Сервис, на который ведет ссылка песни
+type LinkProvider string
+
+const (
+	ProviderYouTube    LinkProvider = "youtube"
+	ProviderSpotify    LinkProvider = "spotify"
+	ProviderBandcamp   LinkProvider = "bandcamp"
+	ProviderSoundCloud LinkProvider = "soundcloud"
+	ProviderAppleMusic LinkProvider = "applemusic"
+	ProviderDeezer     LinkProvider = "deezer"
+	// Любой другой сайт
+	ProviderOther LinkProvider = "other"
+)
+
+var LinkProviders = []LinkProvider{
+	ProviderYouTube, ProviderSpotify, ProviderBandcamp, ProviderSoundCloud,
+	ProviderAppleMusic, ProviderDeezer, ProviderOther,
+}
+
+// Ссылка песни. URL хранится в каноническом виде, Label - необязательная подпись
+type Link struct {
+	Provider LinkProvider
+	URL      string
+	Label    string
+}
+
+// Наибольшая длина ссылки, как у колонки url
+const maxLinkLength = 2048
+
+var youtubeID = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// Проверяет название сервиса и приводит его к нижнему регистру
+func ParseLinkProvider(name string) (LinkProvider, error) {
+	provider := LinkProvider(strings.ToLower(strings.TrimSpace(name)))
+	if !slices.Contains(LinkProviders, provider) {
+		return provider, errors.New("unknown link provider")
+	}
+	return provider, nil
+}
+
+// Определяет сервис по ссылке и приводит ее к каноническому виду, чтобы одна и та же
+// страница не сохранялась дважды. Ролики YouTube из youtu.be, /watch, /shorts и /embed
+// приводятся к https://www.youtube.com/watch?v=ID, у ссылок известных сервисов
+// убираются параметры отслеживания. Ссылки на другие сайты меняются только в регистре хоста
+func NormalizeLink(raw string) (LinkProvider, string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return "", "", errors.New("invalid link")
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+
+	host := strings.TrimPrefix(strings.TrimPrefix(u.Hostname(), "www."), "m.")
+	provider := ProviderOther
+	switch {
+	case host == "youtu.be" || host == "youtube.com" || host == "music.youtube.com":
+		provider = ProviderYouTube
+		if id := youtubeVideo(host, u); id != "" {
+			return provider, "https://www.youtube.com/watch?v=" + id, nil
+		}
+		u.Host = "www.youtube.com"
+	case host == "open.spotify.com":
+		provider = ProviderSpotify
+		u.RawQuery = ""
+	case host == "bandcamp.com" || strings.HasSuffix(host, ".bandcamp.com"):
+		provider = ProviderBandcamp
+		u.Host = host
+		u.RawQuery = ""
+	case host == "soundcloud.com":
+		provider = ProviderSoundCloud
+		u.Host = host
+		u.RawQuery = ""
+	case host == "music.apple.com":
+		// Параметр i указывает трек в альбоме, его нужно сохранить
+		provider = ProviderAppleMusic
+		if track := u.Query().Get("i"); track != "" {
+			u.RawQuery = url.Values{"i": {track}}.Encode()
+		} else {
+			u.RawQuery = ""
+		}
+	case host == "deezer.com":
+		provider = ProviderDeezer
+		u.Host = "www.deezer.com"
+		u.RawQuery = ""
+	}
+
+	if provider != ProviderOther {
+		u.Scheme = "https"
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		u.RawPath = ""
+	}
+
+	link := u.String()
+	if len(link) > maxLinkLength {
+		return "", "", errors.New("invalid link")
+	}
+	return provider, link, nil
+}
+
+// Id ролика YouTube из ссылки, пусто - ссылка ведет не на ролик
+func youtubeVideo(host string, u *url.URL) string {
+	id := ""
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if host == "youtu.be" {
+		id = segments[0]
+	} else if u.Path == "/watch" {
+		id = u.Query().Get("v")
+	} else if len(segments) == 2 && (segments[0] == "shorts" || segments[0] == "embed" || segments[0] == "live") {
+		id = segments[1]
+	}
+	if !youtubeID.MatchString(id) {
+		return ""
+	}
+	return id
+}
@@ -0,0 +1,509 @@
+package database
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"music/tools"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Хранилище песен в памяти, используется в тестах и демо-окружениях без PostgreSQL
+type MemoryRepository struct {
+	mu             sync.RWMutex
+	groups         map[int]string
+	songs          map[int]memorySong
+	revisions      map[int][]SongRevision
+	audit          []AuditEntry
+	albums         map[int]memoryAlbum
+	people         map[int]string
+	memberships    []memoryMembership
+	labels         map[int]map[LabelKind][]string
+	playlists      map[int]memoryPlaylist
+	users          map[int]memoryUser
+	favorites      map[int]map[int]bool
+	ratings        map[int]map[int]int
+	plays          []memoryPlay
+	dailyPlays     map[time.Time]map[int]playTotal
+	links          map[int][]Link
+	nextGroupID    int
+	nextSongID     int
+	nextAlbumID    int
+	nextPersonID   int
+	nextPlaylistID int
+	nextUserID     int
+}
+
+type memorySong struct {
+	publicID    string
+	name        string
+	groupID     int
+	releaseDate time.Time
+	text        string
+	link        string
+	version     int
+	// Агрегаты оценок, поддерживаются при каждой оценке
+	ratingCount int
+	ratingSum   int
+	// Время перемещения в корзину, нулевое для неудаленных песен
+	deletedAt time.Time
+}
+
+func (s memorySong) deleted() bool {
+	return !s.deletedAt.IsZero()
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		groups:         map[int]string{},
+		songs:          map[int]memorySong{},
+		revisions:      map[int][]SongRevision{},
+		albums:         map[int]memoryAlbum{},
+		people:         map[int]string{},
+		labels:         map[int]map[LabelKind][]string{},
+		playlists:      map[int]memoryPlaylist{},
+		users:          map[int]memoryUser{},
+		favorites:      map[int]map[int]bool{},
+		ratings:        map[int]map[int]int{},
+		dailyPlays:     map[time.Time]map[int]playTotal{},
+		links:          map[int][]Link{},
+		nextGroupID:    1,
+		nextSongID:     1,
+		nextAlbumID:    1,
+		nextPersonID:   1,
+		nextPlaylistID: 1,
+		nextUserID:     1,
+	}
+}
+
+// Проверяет существование песни
+func (r *MemoryRepository) Exists(song, group string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.exists(song, group), nil
+}
+
+func (r *MemoryRepository) exists(song, group string) int {
+	for id, s := range r.songs {
+		if s.name == song && r.groups[s.groupID] == group && !s.deleted() {
+			return id
+		}
+	}
+	return -1
+}
+
+// Ищет группу по имени
+func (r *MemoryRepository) groupID(group string) int {
+	for id, name := range r.groups {
+		if name == group {
+			return id
+		}
+	}
+	return -1
+}
+
+// Получает данные о песни по id
+func (r *MemoryRepository) GetSong(id int) (SongData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.getSong(id), nil
+}
+
+func (r *MemoryRepository) getSong(id int) SongData {
+	s, ok := r.songs[id]
+	if !ok || s.deleted() {
+		return SongData{}
+	}
+	return SongData{
+		ID:          id,
+		PublicID:    s.publicID,
+		Song:        s.name,
+		Group:       r.groups[s.groupID],
+		ReleaseDate: s.releaseDate,
+		Text:        s.text,
+		Link:        s.link,
+		Version:     s.version,
+		Rating:      ratingAverage(s.ratingSum, s.ratingCount),
+		Ratings:     s.ratingCount,
+	}
+}
+
+// Получает песню по публичному id
+func (r *MemoryRepository) SongByPublicID(publicID string) (SongData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for id, s := range r.songs {
+		if s.publicID == publicID && !s.deleted() {
+			data := r.getSong(id)
+			tools.Logger.Info(fmt.Sprintf("Got song '%s' by '%s' by id successfully\n", data.Song, data.Group))
+			return data, nil
+		}
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Attempt to get a non-existent song by id: '%s'\n", publicID))
+	return SongData{}, errors.New("song does not exist")
+}
+
+// Добавляет новую песню
+func (r *MemoryRepository) AddSong(data SongData, actor Actor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.exists(data.Song, data.Group) != -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add an existing song: '%s' by '%s'\n", data.Song, data.Group))
+		return errors.New("song already exists")
+	}
+
+	groupID := r.groupID(data.Group)
+	if groupID == -1 {
+		groupID = r.nextGroupID
+		r.nextGroupID++
+		r.groups[groupID] = data.Group
+	}
+
+	id := r.nextSongID
+	r.nextSongID++
+	r.songs[id] = memorySong{
+		publicID:    newPublicID(),
+		name:        data.Song,
+		groupID:     groupID,
+		releaseDate: data.ReleaseDate,
+		text:        data.Text,
+		link:        data.Link,
+		version:     1,
+	}
+	if data.Album != nil {
+		r.addSongAlbum(groupID, id, *data.Album)
+	}
+	r.addSongLinks(id, data.Links)
+	r.recordChange(id, RevisionCreate, actor)
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' added successfully\n", data.Song, data.Group))
+	return nil
+}
+
+// Перемещает песню в корзину
+func (r *MemoryRepository) DeleteSong(song, group string, actor Actor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.exists(song, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to delete a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	r.trashSong(id, actor)
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' deleted successfully\n", song, group))
+	return nil
+}
+
+// Перемещает песню в корзину по внутреннему id
+func (r *MemoryRepository) DeleteSongByID(id int, actor Actor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.songs[id]
+	if !ok || s.deleted() {
+		tools.Logger.Info(fmt.Sprintf("Attempt to delete a non-existent song by id: %d\n", id))
+		return errors.New("song does not exist")
+	}
+
+	r.trashSong(id, actor)
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' deleted successfully\n", s.name, r.groups[s.groupID]))
+	return nil
+}
+
+// Помечает песню удаленной и убирает ее из плейлистов
+func (r *MemoryRepository) trashSong(id int, actor Actor) {
+	s := r.songs[id]
+	s.deletedAt = time.Now()
+	s.version++
+	r.songs[id] = s
+	r.removeSongEntries(id)
+	r.recordChange(id, RevisionDelete, actor)
+}
+
+// Обновляет информацию о песне
+func (r *MemoryRepository) UpdateSong(data SongData, actor Actor) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := -1
+	if data.ID != 0 {
+		// Название и группа берутся из хранилища, песню могли переименовать
+		if s, ok := r.songs[data.ID]; ok && !s.deleted() {
+			id, data.Song, data.Group = data.ID, s.name, r.groups[s.groupID]
+		}
+	} else {
+		id = r.exists(data.Song, data.Group)
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to update a non-existent song: '%s' by '%s'\n", data.Song, data.Group))
+		return 0, errors.New("song does not exist")
+	}
+
+	s := r.songs[id]
+	if len(data.ExpectedVersions) != 0 && !slices.Contains(data.ExpectedVersions, s.version) {
+		tools.Logger.Info(fmt.Sprintf("Attempt to update a modified song: '%s' by '%s', expected versions %v\n", data.Song, data.Group, data.ExpectedVersions))
+		return 0, errors.New("song was modified")
+	}
+	if !data.ReleaseDate.IsZero() {
+		s.releaseDate = data.ReleaseDate
+	}
+	if data.Text != "" {
+		s.text = data.Text
+	}
+	oldLink := s.link
+	if data.Link != "" {
+		s.link = data.Link
+	}
+
+	// Переименование и перенос в другую группу
+	name, group := s.name, data.Group
+	if data.NewSong != "" {
+		name = data.NewSong
+	}
+	if data.NewGroup != "" {
+		group = data.NewGroup
+	}
+	if other := r.exists(name, group); other != -1 && other != id {
+		tools.Logger.Info(fmt.Sprintf("Attempt to rename '%s' by '%s' to an existing song\n", data.Song, data.Group))
+		return 0, errors.New("song already exists")
+	}
+	oldGroupID := s.groupID
+	s.name = name
+	if group != data.Group {
+		s.groupID = r.groupID(group)
+		if s.groupID == -1 {
+			s.groupID = r.nextGroupID
+			r.nextGroupID++
+			r.groups[s.groupID] = group
+		}
+	}
+
+	s.version++
+	r.songs[id] = s
+	if data.Link != "" {
+		r.replaceSongLink(id, oldLink, data.Link)
+	}
+	if s.groupID != oldGroupID {
+		r.removeSongTracks(id)
+		r.deleteEmptyGroup(oldGroupID)
+	}
+	r.recordChange(id, RevisionUpdate, actor)
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' updated successfully\n", data.Song, data.Group))
+	return s.version, nil
+}
+
+// Получает текст песни
+func (r *MemoryRepository) GetText(song, group string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id := r.exists(song, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get text of non-existent song: '%s' by '%s'\n", song, group))
+		return "", errors.New("song does not exist")
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got text of '%s' by '%s' successfully\n", song, group))
+	return r.songs[id].text, nil
+}
+
+// Получает список песен
+func (r *MemoryRepository) ListSongs(filter SongFilter) ([]SongData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	data := []SongData{}
+	for id, s := range r.songs {
+		if s.deleted() {
+			continue
+		}
+		song := r.getSong(id)
+		if filter.matches(song, r.relatedValues(id, song)) {
+			data = append(data, song)
+		}
+	}
+
+	// При чтении назад порядок обратный
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	sort.Slice(data, func(i, j int) bool {
+		result := compareKeys(filter.Sort, sortValues(filter.Sort, data[i]), data[i].ID, sortValues(filter.Sort, data[j]), data[j].ID)
+		if backward {
+			return result > 0
+		}
+		return result < 0
+	})
+
+	// Keyset-пагинация: песни после или перед позицией курсора
+	if filter.Cursor != nil {
+		cursor := filter.Cursor
+		after := []SongData{}
+		for _, song := range data {
+			result := compareKeys(filter.Sort, sortValues(filter.Sort, song), song.ID, cursor.Values, cursor.ID)
+			if backward && result < 0 || !backward && result > 0 {
+				after = append(after, song)
+			}
+		}
+		data = after
+	}
+
+	if filter.Limit != 0 {
+		// Лишняя песня показывает, есть ли следующая страница
+		data = data[:min(filter.Limit+1, len(data))]
+	} else {
+		offset := filter.offset()
+		limit := len(data)
+		if filter.OnPage != 0 {
+			limit = filter.OnPage
+		}
+
+		if offset >= len(data) {
+			data = []SongData{}
+		} else {
+			data = data[offset:min(offset+limit, len(data))]
+		}
+	}
+
+	tools.Logger.Info("Got list of songs successfully")
+	return data, nil
+}
+
+// Сравнивает ключи сортировки с учетом направления полей, при равенстве - по id
+func compareKeys(fields []SortField, leftValues []string, leftID int, rightValues []string, rightID int) int {
+	for i, field := range fields {
+		result := strings.Compare(leftValues[i], rightValues[i])
+		if field.Descending {
+			result = -result
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return cmp.Compare(leftID, rightID)
+}
+
+// Значения полей песни из связанных сущностей: альбомы, участники группы, жанры, теги, сервисы ссылок и избранное
+func (r *MemoryRepository) relatedValues(id int, song SongData) map[FilterField][]string {
+	return map[FilterField][]string{
+		FieldAlbum:      r.songAlbums(id),
+		FieldArtist:     r.artistsAt(r.songs[id].groupID, song.ReleaseDate),
+		FieldGenre:      r.labels[id][LabelGenre],
+		FieldTag:        r.labels[id][LabelTag],
+		FieldProvider:   r.linkProviders(id),
+		fieldFavoriteOf: r.favoriteOf(id),
+	}
+}
+
+// Проверяет, подходит ли песня под фильтр
+func (f SongFilter) matches(song SongData, related map[FilterField][]string) bool {
+	if f.FavoriteOf != "" && !slices.Contains(related[fieldFavoriteOf], f.FavoriteOf) {
+		return false
+	}
+	for _, condition := range f.Conditions {
+		if !condition.matches(song, related) {
+			return false
+		}
+	}
+	return true
+}
+
+// Проверяет, подходит ли песня под условие фильтра
+func (c FilterCondition) matches(song SongData, related map[FilterField][]string) bool {
+	var value string
+	switch c.Field {
+	case FieldSong:
+		value = song.Song
+	case FieldGroup:
+		value = song.Group
+	case FieldReleaseDate:
+		value = song.ReleaseDate.Format("2006-01-02")
+	case FieldText:
+		value = song.Text
+	case FieldLink:
+		value = song.Link
+	case FieldAlbum, FieldArtist, FieldGenre, FieldTag, FieldProvider:
+		if c.Operator == OperatorAll {
+			for _, expected := range c.Values {
+				if !slices.Contains(related[c.Field], expected.(string)) {
+					return false
+				}
+			}
+			return true
+		}
+		for _, value := range related[c.Field] {
+			if c.matchesValue(value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+
+	return c.matchesValue(value)
+}
+
+// Проверяет, подходит ли значение поля под условие фильтра
+func (c FilterCondition) matchesValue(value string) bool {
+	// Даты сравниваются в формате ISO, который упорядочивается так же, как строки
+	for _, expected := range c.Values {
+		if date, ok := expected.(time.Time); ok {
+			expected = date.Format("2006-01-02")
+		}
+		switch c.Operator {
+		case OperatorIn:
+			if value == expected {
+				return true
+			}
+		case OperatorGTE:
+			if value >= expected.(string) {
+				return true
+			}
+		case OperatorLTE:
+			if value <= expected.(string) {
+				return true
+			}
+		case OperatorRange:
+			dates := expected.(DateRange)
+			if value >= dates.From.Format("2006-01-02") && value < dates.To.Format("2006-01-02") {
+				return true
+			}
+		case OperatorIEq:
+			if strings.EqualFold(value, expected.(string)) {
+				return true
+			}
+		case OperatorContains:
+			if strings.Contains(value, expected.(string)) {
+				return true
+			}
+		case OperatorIContains:
+			if strings.Contains(strings.ToLower(value), strings.ToLower(expected.(string))) {
+				return true
+			}
+		case OperatorPrefix:
+			if strings.HasPrefix(value, expected.(string)) {
+				return true
+			}
+		case OperatorIPrefix:
+			if strings.HasPrefix(strings.ToLower(value), strings.ToLower(expected.(string))) {
+				return true
+			}
+		case OperatorSimilar:
+			if trigramSimilarity(value, expected.(string)) >= similarityThreshold {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,276 @@
+package database
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"music/tools"
+	"slices"
+	"time"
+)
+
+type memoryAlbum struct {
+	title       string
+	groupID     int
+	releaseDate time.Time
+	albumType   string
+	tracks      []memoryTrack
+}
+
+type memoryTrack struct {
+	songID int
+	disc   int
+	track  int
+}
+
+// Ищет альбом группы, -1 - альбома нет
+func (r *MemoryRepository) albumID(album, group string) int {
+	for id, a := range r.albums {
+		if a.title == album && r.groups[a.groupID] == group {
+			return id
+		}
+	}
+	return -1
+}
+
+// Названия альбомов, на которых есть песня
+func (r *MemoryRepository) songAlbums(songID int) []string {
+	titles := []string{}
+	for _, a := range r.albums {
+		for _, t := range a.tracks {
+			if t.songID == songID {
+				titles = append(titles, a.title)
+			}
+		}
+	}
+	return titles
+}
+
+// Добавляет песню на альбом из данных music info API. Существующий альбом
+// не перезаписывается, а если место на альбоме уже занято, песня на него не попадает
+func (r *MemoryRepository) addSongAlbum(groupID, songID int, album SongAlbum) {
+	id := r.albumID(album.Title, r.groups[groupID])
+	if id == -1 {
+		id = r.nextAlbumID
+		r.nextAlbumID++
+		r.albums[id] = memoryAlbum{
+			title:       album.Title,
+			groupID:     groupID,
+			releaseDate: album.ReleaseDate,
+			albumType:   album.Type,
+		}
+	}
+
+	a := r.albums[id]
+	if a.releaseDate.IsZero() {
+		a.releaseDate = album.ReleaseDate
+	}
+	for _, t := range a.tracks {
+		if t.songID == songID || t.disc == album.Disc && t.track == album.Track {
+			r.albums[id] = a
+			return
+		}
+	}
+	a.tracks = append(slices.Clone(a.tracks), memoryTrack{songID: songID, disc: album.Disc, track: album.Track})
+	r.albums[id] = a
+}
+
+// Получает альбомы с песнями
+func (r *MemoryRepository) ListAlbums(group, album string) ([]Album, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	albums := []Album{}
+	for id, a := range r.albums {
+		if group != "" && r.groups[a.groupID] != group || album != "" && a.title != album {
+			continue
+		}
+
+		temp := Album{
+			ID:          id,
+			Title:       a.title,
+			Group:       r.groups[a.groupID],
+			ReleaseDate: a.releaseDate,
+			Type:        a.albumType,
+			Tracks:      []AlbumTrack{},
+		}
+		for _, t := range a.tracks {
+			// Песни из корзины в список треков не попадают
+			if r.songs[t.songID].deleted() {
+				continue
+			}
+			temp.Tracks = append(temp.Tracks, AlbumTrack{Song: r.songs[t.songID].name, Disc: t.disc, Track: t.track})
+		}
+		slices.SortFunc(temp.Tracks, func(x, y AlbumTrack) int {
+			return cmp.Or(cmp.Compare(x.Disc, y.Disc), cmp.Compare(x.Track, y.Track))
+		})
+		albums = append(albums, temp)
+	}
+
+	// Порядок как в PostgreSQL: группа, дата выхода (неизвестная - в конце), название
+	slices.SortFunc(albums, func(x, y Album) int {
+		if result := cmp.Compare(x.Group, y.Group); result != 0 {
+			return result
+		}
+		if x.ReleaseDate.IsZero() != y.ReleaseDate.IsZero() {
+			if x.ReleaseDate.IsZero() {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Or(x.ReleaseDate.Compare(y.ReleaseDate), cmp.Compare(x.Title, y.Title), cmp.Compare(x.ID, y.ID))
+	})
+
+	tools.Logger.Info("Got list of albums successfully")
+	return albums, nil
+}
+
+// Добавляет новый альбом
+func (r *MemoryRepository) AddAlbum(album Album) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.albumID(album.Title, album.Group) != -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add an existing album: '%s' by '%s'\n", album.Title, album.Group))
+		return errors.New("album already exists")
+	}
+
+	groupID := r.groupID(album.Group)
+	if groupID == -1 {
+		groupID = r.nextGroupID
+		r.nextGroupID++
+		r.groups[groupID] = album.Group
+	}
+
+	r.albums[r.nextAlbumID] = memoryAlbum{
+		title:       album.Title,
+		groupID:     groupID,
+		releaseDate: album.ReleaseDate,
+		albumType:   album.Type,
+	}
+	r.nextAlbumID++
+
+	tools.Logger.Info(fmt.Sprintf("Album '%s' by '%s' added successfully\n", album.Title, album.Group))
+	return nil
+}
+
+// Обновляет дату выхода и тип альбома, пустые поля остаются без изменений
+func (r *MemoryRepository) UpdateAlbum(album Album) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.albumID(album.Title, album.Group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to update a non-existent album: '%s' by '%s'\n", album.Title, album.Group))
+		return errors.New("album does not exist")
+	}
+
+	a := r.albums[id]
+	if !album.ReleaseDate.IsZero() {
+		a.releaseDate = album.ReleaseDate
+	}
+	if album.Type != "" {
+		a.albumType = album.Type
+	}
+	r.albums[id] = a
+
+	tools.Logger.Info(fmt.Sprintf("Album '%s' by '%s' updated successfully\n", album.Title, album.Group))
+	return nil
+}
+
+// Удаляет альбом. Песни альбома остаются
+func (r *MemoryRepository) DeleteAlbum(album, group string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.albumID(album, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to delete a non-existent album: '%s' by '%s'\n", album, group))
+		return errors.New("album does not exist")
+	}
+
+	groupID := r.albums[id].groupID
+	delete(r.albums, id)
+	r.deleteEmptyGroup(groupID)
+
+	tools.Logger.Info(fmt.Sprintf("Album '%s' by '%s' deleted successfully\n", album, group))
+	return nil
+}
+
+// Добавляет песню группы на ее альбом
+func (r *MemoryRepository) AddTrack(album, group, song string, disc, track int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.albumID(album, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add a track to a non-existent album: '%s' by '%s'\n", album, group))
+		return errors.New("album does not exist")
+	}
+	songID := r.exists(song, group)
+	if songID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add a non-existent song to an album: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	a := r.albums[id]
+	for _, t := range a.tracks {
+		if t.songID == songID {
+			tools.Logger.Info(fmt.Sprintf("Attempt to add song '%s' to album '%s' by '%s' twice\n", song, album, group))
+			return errors.New("song is already on album")
+		}
+		if t.disc == disc && t.track == track {
+			tools.Logger.Info(fmt.Sprintf("Attempt to add a track to a taken place %d-%d on '%s' by '%s'\n", disc, track, album, group))
+			return errors.New("track is taken")
+		}
+	}
+	a.tracks = append(slices.Clone(a.tracks), memoryTrack{songID: songID, disc: disc, track: track})
+	r.albums[id] = a
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' added to album '%s' by '%s' successfully\n", song, album, group))
+	return nil
+}
+
+// Убирает песню с альбома
+func (r *MemoryRepository) RemoveTrack(album, group, song string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.albumID(album, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove a track from a non-existent album: '%s' by '%s'\n", album, group))
+		return errors.New("album does not exist")
+	}
+	songID := r.exists(song, group)
+	if songID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove a non-existent song from an album: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	a := r.albums[id]
+	tracks := slices.DeleteFunc(slices.Clone(a.tracks), func(t memoryTrack) bool {
+		return t.songID == songID
+	})
+	if len(tracks) == len(a.tracks) {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove song '%s' that is not on album '%s' by '%s'\n", song, album, group))
+		return errors.New("song is not on album")
+	}
+	a.tracks = tracks
+	r.albums[id] = a
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' removed from album '%s' by '%s' successfully\n", song, album, group))
+	return nil
+}
+
+// Убирает окончательно удаленную песню со всех альбомов
+func (r *MemoryRepository) removeSongTracks(songID int) {
+	for id, a := range r.albums {
+		tracks := slices.DeleteFunc(slices.Clone(a.tracks), func(t memoryTrack) bool {
+			return t.songID == songID
+		})
+		if len(tracks) != len(a.tracks) {
+			a.tracks = tracks
+			r.albums[id] = a
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"encoding/json"
+	"music/tools"
+)
+
+// Сохраняет ревизию песни и запись журнала аудита с состояниями до и после изменения
+func (r *MemoryRepository) recordChange(id int, operation string, actor Actor) {
+	r.addRevision(id, operation)
+
+	revisions := r.revisions[id]
+	current := revisions[len(revisions)-1]
+	entry := AuditEntry{
+		ID:        len(r.audit) + 1,
+		Song:      current.Song,
+		Group:     current.Group,
+		Operation: operation,
+		Actor:     actor.Name,
+		RequestID: actor.RequestID,
+		CreatedAt: current.CreatedAt,
+	}
+	if auditHasBefore(operation) && len(revisions) > 1 {
+		entry.Before = revisionImage(revisions[len(revisions)-2])
+	}
+	if auditHasAfter(operation) {
+		entry.After = revisionImage(current)
+	}
+	r.audit = append(r.audit, entry)
+}
+
+// Состояние песни из ревизии в виде JSON
+func revisionImage(revision SongRevision) json.RawMessage {
+	image, _ := json.Marshal(auditImage{
+		Song:        revision.Song,
+		Group:       revision.Group,
+		ReleaseDate: revision.ReleaseDate.Format("2006-01-02"),
+		Text:        revision.Text,
+		Link:        revision.Link,
+	})
+	return image
+}
+
+// Получает записи журнала аудита, от новых к старым
+func (r *MemoryRepository) ListAudit(filter AuditFilter) ([]AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []AuditEntry{}
+	for i := len(r.audit) - 1; i >= 0; i-- {
+		entry := r.audit[i]
+		if len(entries) == filter.Limit {
+			break
+		}
+		if filter.Song != "" && entry.Song != filter.Song ||
+			filter.Group != "" && entry.Group != filter.Group ||
+			filter.Actor != "" && entry.Actor != filter.Actor ||
+			!filter.From.IsZero() && entry.CreatedAt.Before(filter.From) ||
+			!filter.To.IsZero() && !entry.CreatedAt.Before(filter.To) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	tools.Logger.Info("Got audit log successfully")
+	return entries, nil
+}
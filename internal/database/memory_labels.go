@@ -0,0 +1,131 @@
+package database
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"music/tools"
+	"slices"
+)
+
+// Добавляет метки песне, метки хранятся отсортированными и без повторов
+func (r *MemoryRepository) attachLabels(id int, kind LabelKind, names []string) {
+	if r.labels[id] == nil {
+		r.labels[id] = map[LabelKind][]string{}
+	}
+	labels := append(slices.Clone(r.labels[id][kind]), names...)
+	slices.Sort(labels)
+	r.labels[id][kind] = slices.Compact(labels)
+}
+
+// Получает метки песни по видам
+func (r *MemoryRepository) SongLabels(song, group string) (map[LabelKind][]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	labels := map[LabelKind][]string{LabelGenre: {}, LabelTag: {}}
+	id := r.exists(song, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get labels of non-existent song: '%s' by '%s'\n", song, group))
+		return labels, errors.New("song does not exist")
+	}
+
+	for kind, names := range r.labels[id] {
+		labels[kind] = slices.Clone(names)
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got labels of '%s' by '%s' successfully\n", song, group))
+	return labels, nil
+}
+
+// Добавляет песне метки всех видов
+func (r *MemoryRepository) AttachLabels(song, group string, labels map[LabelKind][]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.exists(song, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to label a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+	for kind, names := range labels {
+		r.attachLabels(id, kind, names)
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' labeled successfully\n", song, group))
+	return nil
+}
+
+// Убирает метки песни всех видов, отсутствующие метки пропускаются
+func (r *MemoryRepository) DetachLabels(song, group string, labels map[LabelKind][]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.exists(song, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to unlabel a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+	if r.labels[id] != nil {
+		for kind, names := range labels {
+			r.labels[id][kind] = slices.DeleteFunc(slices.Clone(r.labels[id][kind]), func(name string) bool {
+				return slices.Contains(names, name)
+			})
+		}
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Labels removed from '%s' by '%s' successfully\n", song, group))
+	return nil
+}
+
+// Добавляет метки всем песням, подходящим под условия фильтра. Пагинация фильтра не учитывается
+func (r *MemoryRepository) LabelSongs(filter SongFilter, labels map[LabelKind][]string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := []int{}
+	for id, s := range r.songs {
+		if s.deleted() {
+			continue
+		}
+		song := r.getSong(id)
+		if filter.matches(song, r.relatedValues(id, song)) {
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range ids {
+		for kind, names := range labels {
+			r.attachLabels(id, kind, names)
+		}
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Labeled %d songs successfully\n", len(ids)))
+	return int64(len(ids)), nil
+}
+
+// Получает метки вида kind, от самых популярных
+func (r *MemoryRepository) ListLabels(kind LabelKind) ([]Label, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := map[string]int{}
+	for id, labels := range r.labels {
+		if s, ok := r.songs[id]; !ok || s.deleted() {
+			continue
+		}
+		for _, name := range labels[kind] {
+			counts[name]++
+		}
+	}
+
+	labels := []Label{}
+	for name, songs := range counts {
+		labels = append(labels, Label{Name: name, Songs: songs})
+	}
+	slices.SortFunc(labels, func(x, y Label) int {
+		return cmp.Or(cmp.Compare(y.Songs, x.Songs), cmp.Compare(x.Name, y.Name))
+	})
+
+	tools.Logger.Info(fmt.Sprintf("Got list of %s labels successfully", kind))
+	return labels, nil
+}
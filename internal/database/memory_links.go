@@ -0,0 +1,101 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"music/tools"
+	"slices"
+)
+
+// Добавляет ссылки песни, повторяющиеся ссылки пропускаются
+func (r *MemoryRepository) addSongLinks(songID int, links []Link) bool {
+	added := false
+	for _, link := range links {
+		if slices.ContainsFunc(r.links[songID], func(l Link) bool { return l.URL == link.URL }) {
+			continue
+		}
+		r.links[songID] = append(r.links[songID], link)
+		added = true
+	}
+	return added
+}
+
+// Заменяет основную ссылку песни old на new, остальные ссылки песни не меняются
+func (r *MemoryRepository) replaceSongLink(songID int, old, new string) {
+	_, oldURL, oldErr := NormalizeLink(old)
+	provider, newURL, newErr := NormalizeLink(new)
+	if oldErr == nil && newErr == nil && oldURL == newURL {
+		return
+	}
+
+	if oldErr == nil {
+		r.links[songID] = slices.DeleteFunc(slices.Clone(r.links[songID]), func(l Link) bool { return l.URL == oldURL })
+	}
+	if newErr == nil {
+		r.addSongLinks(songID, []Link{{Provider: provider, URL: newURL}})
+	}
+}
+
+// Сервисы ссылок песни
+func (r *MemoryRepository) linkProviders(songID int) []string {
+	providers := []string{}
+	for _, link := range r.links[songID] {
+		providers = append(providers, string(link.Provider))
+	}
+	return providers
+}
+
+// Получает ссылки песни по порядку добавления
+func (r *MemoryRepository) SongLinks(song, group string) ([]Link, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id := r.exists(song, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get links of a non-existent song: '%s' by '%s'\n", song, group))
+		return []Link{}, errors.New("song does not exist")
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got links of '%s' by '%s' successfully\n", song, group))
+	return append([]Link{}, r.links[id]...), nil
+}
+
+// Добавляет песне ссылку
+func (r *MemoryRepository) AddLink(song, group string, link Link) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.exists(song, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add a link to a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+	if !r.addSongLinks(id, []Link{link}) {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add an existing link '%s' to '%s' by '%s'\n", link.URL, song, group))
+		return errors.New("link already exists")
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Link '%s' added to '%s' by '%s' successfully\n", link.URL, song, group))
+	return nil
+}
+
+// Убирает ссылку песни
+func (r *MemoryRepository) DeleteLink(song, group, url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.exists(song, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove a link of a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+	index := slices.IndexFunc(r.links[id], func(l Link) bool { return l.URL == url })
+	if index == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove a non-existent link '%s' of '%s' by '%s'\n", url, song, group))
+		return errors.New("link does not exist")
+	}
+	r.links[id] = slices.Delete(slices.Clone(r.links[id]), index, index+1)
+
+	tools.Logger.Info(fmt.Sprintf("Link '%s' removed from '%s' by '%s' successfully\n", url, song, group))
+	return nil
+}
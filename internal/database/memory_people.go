@@ -0,0 +1,161 @@
+package database
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"music/tools"
+	"slices"
+	"time"
+)
+
+type memoryMembership struct {
+	personID int
+	groupID  int
+	role     string
+	from     time.Time
+	to       time.Time
+}
+
+// Ищет человека по имени
+func (r *MemoryRepository) personID(person string) int {
+	for id, name := range r.people {
+		if name == person {
+			return id
+		}
+	}
+	return -1
+}
+
+func (r *MemoryRepository) membership(m memoryMembership) Membership {
+	return Membership{
+		Person: r.people[m.personID],
+		Group:  r.groups[m.groupID],
+		Role:   m.role,
+		From:   m.from,
+		To:     m.to,
+	}
+}
+
+// Имена участников группы в указанный день
+func (r *MemoryRepository) artistsAt(groupID int, date time.Time) []string {
+	artists := []string{}
+	for _, m := range r.memberships {
+		if m.groupID == groupID && r.membership(m).ActiveAt(date) {
+			artists = append(artists, r.people[m.personID])
+		}
+	}
+	return artists
+}
+
+// Добавляет период участия человека в группе, человек и группа создаются при необходимости
+func (r *MemoryRepository) AddMembership(membership Membership) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	groupID := r.groupID(membership.Group)
+	personID := r.personID(membership.Person)
+	for _, m := range r.memberships {
+		if m.personID == personID && m.groupID == groupID && m.role == membership.Role && m.from.Equal(membership.From) {
+			tools.Logger.Info(fmt.Sprintf("Attempt to add an existing membership: '%s' in '%s' as '%s'\n", membership.Person, membership.Group, membership.Role))
+			return errors.New("membership already exists")
+		}
+	}
+
+	if groupID == -1 {
+		groupID = r.nextGroupID
+		r.nextGroupID++
+		r.groups[groupID] = membership.Group
+	}
+	if personID == -1 {
+		personID = r.nextPersonID
+		r.nextPersonID++
+		r.people[personID] = membership.Person
+	}
+	r.memberships = append(r.memberships, memoryMembership{
+		personID: personID,
+		groupID:  groupID,
+		role:     membership.Role,
+		from:     membership.From,
+		to:       membership.To,
+	})
+
+	tools.Logger.Info(fmt.Sprintf("Membership of '%s' in '%s' as '%s' added successfully\n", membership.Person, membership.Group, membership.Role))
+	return nil
+}
+
+// Удаляет период участия. Человек без участий и пустая группа удаляются
+func (r *MemoryRepository) DeleteMembership(person, group, role string, from time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	personID, groupID := r.personID(person), r.groupID(group)
+	memberships := slices.DeleteFunc(slices.Clone(r.memberships), func(m memoryMembership) bool {
+		return m.personID == personID && m.groupID == groupID && m.role == role && (from.IsZero() || m.from.Equal(from))
+	})
+	if personID == -1 || groupID == -1 || len(memberships) == len(r.memberships) {
+		tools.Logger.Info(fmt.Sprintf("Attempt to delete a non-existent membership: '%s' in '%s' as '%s'\n", person, group, role))
+		return errors.New("membership does not exist")
+	}
+	r.memberships = memberships
+
+	if !slices.ContainsFunc(r.memberships, func(m memoryMembership) bool { return m.personID == personID }) {
+		delete(r.people, personID)
+	}
+	r.deleteEmptyGroup(groupID)
+
+	tools.Logger.Info(fmt.Sprintf("Membership of '%s' in '%s' as '%s' deleted successfully\n", person, group, role))
+	return nil
+}
+
+// Получает участников группы в указанный день
+func (r *MemoryRepository) ListLineup(group string, date time.Time) ([]Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lineup := []Membership{}
+	groupID := r.groupID(group)
+	if groupID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get lineup of a non-existent group: '%s'\n", group))
+		return lineup, errors.New("group does not exist")
+	}
+
+	for _, m := range r.memberships {
+		membership := r.membership(m)
+		if m.groupID == groupID && membership.ActiveAt(date) {
+			lineup = append(lineup, membership)
+		}
+	}
+	slices.SortFunc(lineup, func(x, y Membership) int {
+		return cmp.Or(cmp.Compare(x.Person, y.Person), cmp.Compare(x.Role, y.Role))
+	})
+
+	tools.Logger.Info(fmt.Sprintf("Got lineup of '%s' successfully\n", group))
+	return lineup, nil
+}
+
+// Получает все периоды участия человека в группах
+func (r *MemoryRepository) ListMemberships(person string) ([]Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	memberships := []Membership{}
+	personID := r.personID(person)
+	if personID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get groups of a non-existent person: '%s'\n", person))
+		return memberships, errors.New("person does not exist")
+	}
+
+	for _, m := range r.memberships {
+		if m.personID == personID {
+			memberships = append(memberships, r.membership(m))
+		}
+	}
+	// Неизвестная дата начала - в начале списка, как NULLS FIRST
+	slices.SortFunc(memberships, func(x, y Membership) int {
+		return cmp.Or(x.From.Compare(y.From), cmp.Compare(x.Group, y.Group), cmp.Compare(x.Role, y.Role))
+	})
+
+	tools.Logger.Info(fmt.Sprintf("Got groups of '%s' successfully\n", person))
+	return memberships, nil
+}
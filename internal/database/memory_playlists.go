@@ -0,0 +1,214 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"music/tools"
+	"slices"
+	"sort"
+	"time"
+)
+
+type memoryPlaylist struct {
+	name      string
+	createdAt time.Time
+	// id песен по порядку позиций
+	songs []int
+}
+
+// Ищет плейлист, -1 - плейлиста нет
+func (r *MemoryRepository) playlistID(name string) int {
+	for id, p := range r.playlists {
+		if p.name == name {
+			return id
+		}
+	}
+	return -1
+}
+
+// Убирает песню из всех плейлистов, следующие песни сдвигаются вверх
+func (r *MemoryRepository) removeSongEntries(songID int) {
+	for id, p := range r.playlists {
+		if slices.Contains(p.songs, songID) {
+			p.songs = slices.DeleteFunc(slices.Clone(p.songs), func(entry int) bool { return entry == songID })
+			r.playlists[id] = p
+		}
+	}
+}
+
+// Получает плейлисты с числом песен
+func (r *MemoryRepository) ListPlaylists() ([]Playlist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	playlists := []Playlist{}
+	for id, p := range r.playlists {
+		playlists = append(playlists, Playlist{ID: id, Name: p.name, CreatedAt: p.createdAt, Songs: len(p.songs)})
+	}
+	sort.Slice(playlists, func(i, j int) bool {
+		return playlists[i].Name < playlists[j].Name
+	})
+
+	tools.Logger.Info("Got list of playlists successfully")
+	return playlists, nil
+}
+
+// Получает плейлист с песнями по порядку
+func (r *MemoryRepository) GetPlaylist(name string) (Playlist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	playlist := Playlist{Entries: []PlaylistEntry{}}
+	id := r.playlistID(name)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get a non-existent playlist: '%s'\n", name))
+		return playlist, errors.New("playlist does not exist")
+	}
+
+	p := r.playlists[id]
+	playlist.ID, playlist.Name, playlist.CreatedAt, playlist.Songs = id, p.name, p.createdAt, len(p.songs)
+	for i, songID := range p.songs {
+		playlist.Entries = append(playlist.Entries, PlaylistEntry{Position: i + 1, Song: r.getSong(songID)})
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got playlist '%s' successfully\n", name))
+	return playlist, nil
+}
+
+// Создает пустой плейлист
+func (r *MemoryRepository) AddPlaylist(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.playlistID(name) != -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add an existing playlist: '%s'\n", name))
+		return errors.New("playlist already exists")
+	}
+	r.playlists[r.nextPlaylistID] = memoryPlaylist{name: name, createdAt: time.Now()}
+	r.nextPlaylistID++
+
+	tools.Logger.Info(fmt.Sprintf("Playlist '%s' added successfully\n", name))
+	return nil
+}
+
+// Переименовывает плейлист
+func (r *MemoryRepository) RenamePlaylist(name, newName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.playlistID(name)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to rename a non-existent playlist: '%s'\n", name))
+		return errors.New("playlist does not exist")
+	}
+	if other := r.playlistID(newName); other != -1 && other != id {
+		tools.Logger.Info(fmt.Sprintf("Attempt to rename playlist '%s' to an existing name '%s'\n", name, newName))
+		return errors.New("playlist already exists")
+	}
+
+	p := r.playlists[id]
+	p.name = newName
+	r.playlists[id] = p
+
+	tools.Logger.Info(fmt.Sprintf("Playlist '%s' renamed to '%s' successfully\n", name, newName))
+	return nil
+}
+
+// Удаляет плейлист
+func (r *MemoryRepository) DeletePlaylist(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.playlistID(name)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to delete a non-existent playlist: '%s'\n", name))
+		return errors.New("playlist does not exist")
+	}
+	delete(r.playlists, id)
+
+	tools.Logger.Info(fmt.Sprintf("Playlist '%s' deleted successfully\n", name))
+	return nil
+}
+
+// Ставит песню в плейлист на позицию position, 0 - в конец
+func (r *MemoryRepository) AddEntry(playlist, song, group string, position int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	songID := r.exists(song, group)
+	if songID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add a non-existent song to a playlist: '%s' by '%s'\n", song, group))
+		return 0, errors.New("song does not exist")
+	}
+	id := r.playlistID(playlist)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add a song to a non-existent playlist: '%s'\n", playlist))
+		return 0, errors.New("playlist does not exist")
+	}
+
+	p := r.playlists[id]
+	if position == 0 {
+		position = len(p.songs) + 1
+	}
+	if position > len(p.songs)+1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add a song to playlist '%s' at position %d of %d\n", playlist, position, len(p.songs)))
+		return 0, errors.New("position out of range")
+	}
+	p.songs = slices.Insert(slices.Clone(p.songs), position-1, songID)
+	r.playlists[id] = p
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' added to playlist '%s' at position %d successfully\n", song, group, playlist, position))
+	return position, nil
+}
+
+// Убирает песню с позиции плейлиста
+func (r *MemoryRepository) RemoveEntry(playlist string, position int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.playlistID(playlist)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove a song from a non-existent playlist: '%s'\n", playlist))
+		return errors.New("playlist does not exist")
+	}
+
+	p := r.playlists[id]
+	if position > len(p.songs) {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove a non-existent entry %d from playlist '%s'\n", position, playlist))
+		return errors.New("entry does not exist")
+	}
+	p.songs = slices.Delete(slices.Clone(p.songs), position-1, position)
+	r.playlists[id] = p
+
+	tools.Logger.Info(fmt.Sprintf("Entry %d removed from playlist '%s' successfully\n", position, playlist))
+	return nil
+}
+
+// Переставляет песню с позиции from на позицию to
+func (r *MemoryRepository) MoveEntry(playlist string, from, to int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.playlistID(playlist)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to move a song in a non-existent playlist: '%s'\n", playlist))
+		return errors.New("playlist does not exist")
+	}
+
+	p := r.playlists[id]
+	if from > len(p.songs) {
+		tools.Logger.Info(fmt.Sprintf("Attempt to move a non-existent entry %d in playlist '%s'\n", from, playlist))
+		return errors.New("entry does not exist")
+	}
+	if to > len(p.songs) {
+		tools.Logger.Info(fmt.Sprintf("Attempt to move an entry of playlist '%s' to position %d of %d\n", playlist, to, len(p.songs)))
+		return errors.New("position out of range")
+	}
+	songID := p.songs[from-1]
+	songs := slices.Delete(slices.Clone(p.songs), from-1, from)
+	p.songs = slices.Insert(songs, to-1, songID)
+	r.playlists[id] = p
+
+	tools.Logger.Info(fmt.Sprintf("Entry %d of playlist '%s' moved to position %d successfully\n", from, playlist, to))
+	return nil
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"music/tools"
+	"slices"
+	"time"
+)
+
+type memoryPlay struct {
+	songID   int
+	userID   int
+	playedAt time.Time
+	duration int
+}
+
+// Сумма прослушиваний песни за сутки
+type playTotal struct {
+	plays    int64
+	duration int64
+}
+
+// Убирает прослушивания песни и их суточные суммы
+func (r *MemoryRepository) removeSongPlays(songID int) {
+	r.plays = slices.DeleteFunc(r.plays, func(p memoryPlay) bool { return p.songID == songID })
+	for _, totals := range r.dailyPlays {
+		delete(totals, songID)
+	}
+}
+
+// Суммы прослушиваний неудаленных песен за период
+func (r *MemoryRepository) playTotals(period DateRange) map[int]playTotal {
+	totals := map[int]playTotal{}
+	for day := period.From; day.Before(period.To); day = day.AddDate(0, 0, 1) {
+		for songID, t := range r.dailyPlays[day] {
+			if r.songs[songID].deleted() {
+				continue
+			}
+			total := totals[songID]
+			total.plays += t.plays
+			total.duration += t.duration
+			totals[songID] = total
+		}
+	}
+	return totals
+}
+
+// Места песен и групп за период, в том же порядке, что и в PostgresRepository.Chart
+func (r *MemoryRepository) rankPlays(period DateRange) ([]ChartSong, []ChartGroup) {
+	songs := []ChartSong{}
+	groupTotals := map[int]playTotal{}
+	for songID, total := range r.playTotals(period) {
+		s := r.songs[songID]
+		songs = append(songs, ChartSong{
+			PublicID: s.publicID,
+			Song:     s.name,
+			Group:    r.groups[s.groupID],
+			Plays:    total.plays,
+			Duration: total.duration,
+		})
+		group := groupTotals[s.groupID]
+		group.plays += total.plays
+		group.duration += total.duration
+		groupTotals[s.groupID] = group
+	}
+	slices.SortFunc(songs, func(a, b ChartSong) int {
+		return cmp.Or(
+			cmp.Compare(b.Plays, a.Plays),
+			cmp.Compare(b.Duration, a.Duration),
+			cmp.Compare(a.Song, b.Song),
+			cmp.Compare(a.Group, b.Group),
+		)
+	})
+	for i := range songs {
+		songs[i].Rank = i + 1
+	}
+
+	groups := []ChartGroup{}
+	for groupID, total := range groupTotals {
+		groups = append(groups, ChartGroup{Group: r.groups[groupID], Plays: total.plays, Duration: total.duration})
+	}
+	slices.SortFunc(groups, func(a, b ChartGroup) int {
+		return cmp.Or(
+			cmp.Compare(b.Plays, a.Plays),
+			cmp.Compare(b.Duration, a.Duration),
+			cmp.Compare(a.Group, b.Group),
+		)
+	})
+	for i := range groups {
+		groups[i].Rank = i + 1
+	}
+	return songs, groups
+}
+
+// Записывает прослушивание песни пользователем и добавляет его в суточную сумму
+func (r *MemoryRepository) AddPlay(user, song, group string, playedAt time.Time, duration int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.userID(user)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to record a play of a non-existent user: '%s'\n", user))
+		return errors.New("user does not exist")
+	}
+	songID := r.exists(song, group)
+	if songID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to play a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	r.plays = append(r.plays, memoryPlay{songID: songID, userID: id, playedAt: playedAt, duration: duration})
+	day := playDay(playedAt)
+	if r.dailyPlays[day] == nil {
+		r.dailyPlays[day] = map[int]playTotal{}
+	}
+	total := r.dailyPlays[day][songID]
+	total.plays++
+	total.duration += int64(duration)
+	r.dailyPlays[day][songID] = total
+
+	tools.Logger.Info(fmt.Sprintf("Play of '%s' by '%s' by user '%s' recorded successfully\n", song, group, user))
+	return nil
+}
+
+// Получает последние прослушивания пользователя, новые первыми
+func (r *MemoryRepository) ListPlays(user string, limit int) ([]Play, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	plays := []Play{}
+	id := r.userID(user)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get plays of a non-existent user: '%s'\n", user))
+		return plays, errors.New("user does not exist")
+	}
+
+	// Прослушивания хранятся в порядке записи, поэтому при равном времени новее та, что дальше
+	for i := len(r.plays) - 1; i >= 0; i-- {
+		p := r.plays[i]
+		if p.userID != id || r.songs[p.songID].deleted() {
+			continue
+		}
+		s := r.songs[p.songID]
+		plays = append(plays, Play{PublicID: s.publicID, Song: s.name, Group: r.groups[s.groupID], PlayedAt: p.playedAt, Duration: p.duration})
+	}
+	slices.SortStableFunc(plays, func(a, b Play) int {
+		return b.PlayedAt.Compare(a.PlayedAt)
+	})
+	if len(plays) > limit {
+		plays = plays[:limit]
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got plays of user '%s' successfully\n", user))
+	return plays, nil
+}
+
+// Строит чарт по суточным суммам, сами прослушивания не перебираются
+func (r *MemoryRepository) Chart(period, previous DateRange, limit int) (Chart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	songs, groups := r.rankPlays(period)
+	previousSongs, previousGroups := r.rankPlays(previous)
+
+	songRanks := map[[2]string]int{}
+	for _, song := range previousSongs {
+		songRanks[[2]string{song.Song, song.Group}] = song.Rank
+	}
+	groupRanks := map[string]int{}
+	for _, group := range previousGroups {
+		groupRanks[group.Group] = group.Rank
+	}
+
+	chart := Chart{Songs: []ChartSong{}, Groups: []ChartGroup{}}
+	for _, song := range songs[:min(limit, len(songs))] {
+		song.PreviousRank = songRanks[[2]string{song.Song, song.Group}]
+		chart.Songs = append(chart.Songs, song)
+	}
+	for _, group := range groups[:min(limit, len(groups))] {
+		group.PreviousRank = groupRanks[group.Group]
+		chart.Groups = append(chart.Groups, group)
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got chart from %s to %s successfully\n", period.From.Format(time.DateOnly), period.To.Format(time.DateOnly)))
+	return chart, nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"music/tools"
+	"slices"
+	"time"
+)
+
+// Сохраняет текущее состояние песни как новую ревизию
+func (r *MemoryRepository) addRevision(id int, operation string) {
+	s := r.songs[id]
+	r.revisions[id] = append(r.revisions[id], SongRevision{
+		SongData: SongData{
+			ID:          id,
+			Song:        s.name,
+			Group:       r.groups[s.groupID],
+			ReleaseDate: s.releaseDate,
+			Text:        s.text,
+			Link:        s.link,
+		},
+		Revision:  len(r.revisions[id]) + 1,
+		Operation: operation,
+		CreatedAt: time.Now(),
+	})
+}
+
+// Получает ревизии песни, от первой к последней
+func (r *MemoryRepository) ListRevisions(song, group string) ([]SongRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id := r.exists(song, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get revisions of non-existent song: '%s' by '%s'\n", song, group))
+		return []SongRevision{}, errors.New("song does not exist")
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got revisions of '%s' by '%s' successfully\n", song, group))
+	return slices.Clone(r.revisions[id]), nil
+}
+
+// Получает одну ревизию песни
+func (r *MemoryRepository) GetRevision(song, group string, revision int) (SongRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id := r.exists(song, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get revision of non-existent song: '%s' by '%s'\n", song, group))
+		return SongRevision{}, errors.New("song does not exist")
+	}
+	if revision < 1 || revision > len(r.revisions[id]) {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get non-existent revision %d of '%s' by '%s'\n", revision, song, group))
+		return SongRevision{}, errors.New("revision does not exist")
+	}
+
+	return r.revisions[id][revision-1], nil
+}
+
+// Возвращает дату выхода, текст и ссылку песни к состоянию из ревизии
+func (r *MemoryRepository) RevertSong(song, group string, revision int, actor Actor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.exists(song, group)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to revert a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+	if revision < 1 || revision > len(r.revisions[id]) {
+		tools.Logger.Info(fmt.Sprintf("Attempt to revert to non-existent revision %d of '%s' by '%s'\n", revision, song, group))
+		return errors.New("revision does not exist")
+	}
+
+	target := r.revisions[id][revision-1]
+	s := r.songs[id]
+	s.releaseDate = target.ReleaseDate
+	s.text = target.Text
+	r.replaceSongLink(id, s.link, target.Link)
+	s.link = target.Link
+	s.version++
+	r.songs[id] = s
+	r.recordChange(id, RevisionRevert, actor)
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' reverted to revision %d successfully\n", song, group, revision))
+	return nil
+}
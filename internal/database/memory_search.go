@@ -0,0 +1,124 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"music/tools"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Ищет песни по тексту. В отличие от PostgreSQL слова не приводятся к основе,
+// поэтому конфигурация языка только проверяется
+func (r *MemoryRepository) SearchText(query SearchQuery) ([]SearchResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := []SearchResult{}
+	if !IsSearchLanguage(query.Language) {
+		return results, errors.New("unsupported language")
+	}
+
+	terms := map[string]bool{}
+	for _, word := range splitWords(query.Text) {
+		terms[word] = true
+	}
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	ids := make([]int, 0, len(r.songs))
+	for id := range r.songs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		song := r.songs[id]
+		if song.deleted() {
+			continue
+		}
+		words := splitWords(song.text)
+
+		found := map[string]bool{}
+		hits := 0
+		for _, word := range words {
+			if terms[word] {
+				found[word] = true
+				hits++
+			}
+		}
+		if len(found) != len(terms) {
+			continue
+		}
+
+		results = append(results, SearchResult{
+			PublicID:    song.publicID,
+			Song:        song.name,
+			Group:       r.groups[song.groupID],
+			ReleaseDate: song.releaseDate,
+			Rank:        float64(hits) / float64(len(words)),
+			Snippet:     highlight(song.text, terms),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if query.Limit != 0 && len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Found %d songs by text '%s'", len(results), query.Text))
+	return results, nil
+}
+
+// Разбивает текст на слова в нижнем регистре
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// Собирает фрагмент из строк с найденными словами и подсвечивает их
+func highlight(text string, terms map[string]bool) string {
+	fragments := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		var builder strings.Builder
+		matched := false
+		word := []rune{}
+
+		flush := func() {
+			if len(word) == 0 {
+				return
+			}
+			if terms[strings.ToLower(string(word))] {
+				matched = true
+				builder.WriteString(highlightStart + html.EscapeString(string(word)) + highlightStop)
+			} else {
+				builder.WriteString(html.EscapeString(string(word)))
+			}
+			word = word[:0]
+		}
+
+		for _, r := range line {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' {
+				word = append(word, r)
+				continue
+			}
+			flush()
+			builder.WriteString(html.EscapeString(string(r)))
+		}
+		flush()
+
+		if matched {
+			fragments = append(fragments, builder.String())
+			if len(fragments) == 3 {
+				break
+			}
+		}
+	}
+	return strings.Join(fragments, " ... ")
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"fmt"
+	"music/tools"
+	"sort"
+)
+
+// Ищет песни, похожие по названию и группе на искомую
+func (r *MemoryRepository) SimilarSongs(song, group string, limit int) ([]SongCandidate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]int, 0, len(r.songs))
+	for id := range r.songs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	candidates := []SongCandidate{}
+	for _, id := range ids {
+		s := r.songs[id]
+		if s.deleted() {
+			continue
+		}
+		songSimilarity := trigramSimilarity(s.name, song)
+		groupSimilarity := trigramSimilarity(r.groups[s.groupID], group)
+		if songSimilarity < similarityThreshold && groupSimilarity < similarityThreshold {
+			continue
+		}
+		candidates = append(candidates, SongCandidate{
+			Song:       s.name,
+			Group:      r.groups[s.groupID],
+			Similarity: (songSimilarity + groupSimilarity) / 2,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Similarity > candidates[j].Similarity
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Found %d songs similar to '%s' by '%s'", len(candidates), song, group))
+	return candidates, nil
+}
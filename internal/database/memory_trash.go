@@ -0,0 +1,122 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"music/tools"
+	"sort"
+	"time"
+)
+
+// Получает песни из корзины, сначала удаленные последними
+func (r *MemoryRepository) ListTrash() ([]TrashedSong, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	songs := []TrashedSong{}
+	for id, s := range r.songs {
+		if !s.deleted() {
+			continue
+		}
+		songs = append(songs, TrashedSong{
+			SongData: SongData{
+				ID:          id,
+				PublicID:    s.publicID,
+				Song:        s.name,
+				Group:       r.groups[s.groupID],
+				ReleaseDate: s.releaseDate,
+				Text:        s.text,
+				Link:        s.link,
+			},
+			DeletedAt: s.deletedAt,
+		})
+	}
+
+	sort.Slice(songs, func(i, j int) bool {
+		if !songs[i].DeletedAt.Equal(songs[j].DeletedAt) {
+			return songs[i].DeletedAt.After(songs[j].DeletedAt)
+		}
+		return songs[i].ID > songs[j].ID
+	})
+
+	tools.Logger.Info("Got list of trashed songs successfully")
+	return songs, nil
+}
+
+// Восстанавливает из корзины последнюю удаленную версию песни
+func (r *MemoryRepository) RestoreSong(song, group string, actor Actor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	restoreID := -1
+	for id, s := range r.songs {
+		if !s.deleted() || s.name != song || r.groups[s.groupID] != group {
+			continue
+		}
+		if restoreID == -1 || s.deletedAt.After(r.songs[restoreID].deletedAt) {
+			restoreID = id
+		}
+	}
+	if restoreID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to restore a song that is not in trash: '%s' by '%s'\n", song, group))
+		return errors.New("song is not in trash")
+	}
+	if r.exists(song, group) != -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to restore a song that already exists: '%s' by '%s'\n", song, group))
+		return errors.New("song already exists")
+	}
+
+	s := r.songs[restoreID]
+	s.deletedAt = time.Time{}
+	s.version++
+	r.songs[restoreID] = s
+	r.recordChange(restoreID, RevisionRestore, actor)
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' restored successfully\n", song, group))
+	return nil
+}
+
+// Окончательно удаляет песни, попавшие в корзину раньше указанного времени
+func (r *MemoryRepository) PurgeTrash(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, s := range r.songs {
+		if s.deleted() && s.deletedAt.Before(before) {
+			delete(r.songs, id)
+			delete(r.revisions, id)
+			r.removeSongTracks(id)
+			delete(r.labels, id)
+			delete(r.favorites, id)
+			delete(r.ratings, id)
+			r.removeSongPlays(id)
+			delete(r.links, id)
+			r.deleteEmptyGroup(s.groupID)
+			purged++
+		}
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Purged %d songs from trash", purged))
+	return purged, nil
+}
+
+// Повторяет поведение триггера delete_empty_group: группа с песнями, альбомами или участниками остается
+func (r *MemoryRepository) deleteEmptyGroup(groupID int) {
+	for _, s := range r.songs {
+		if s.groupID == groupID {
+			return
+		}
+	}
+	for _, a := range r.albums {
+		if a.groupID == groupID {
+			return
+		}
+	}
+	for _, m := range r.memberships {
+		if m.groupID == groupID {
+			return
+		}
+	}
+	delete(r.groups, groupID)
+}
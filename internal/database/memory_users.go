@@ -0,0 +1,144 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"music/tools"
+	"time"
+)
+
+// Ключ relatedValues с именами пользователей, добавивших песню в избранное
+const fieldFavoriteOf FilterField = "favoriteof"
+
+type memoryUser struct {
+	name      string
+	createdAt time.Time
+}
+
+// Ищет пользователя, -1 - пользователя нет
+func (r *MemoryRepository) userID(name string) int {
+	for id, u := range r.users {
+		if u.name == name {
+			return id
+		}
+	}
+	return -1
+}
+
+// Имена пользователей, добавивших песню в избранное
+func (r *MemoryRepository) favoriteOf(songID int) []string {
+	names := []string{}
+	for id := range r.favorites[songID] {
+		names = append(names, r.users[id].name)
+	}
+	return names
+}
+
+// Регистрирует пользователя
+func (r *MemoryRepository) AddUser(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.userID(name) != -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add an existing user: '%s'\n", name))
+		return errors.New("user already exists")
+	}
+	r.users[r.nextUserID] = memoryUser{name: name, createdAt: time.Now()}
+	r.nextUserID++
+
+	tools.Logger.Info(fmt.Sprintf("User '%s' added successfully\n", name))
+	return nil
+}
+
+// Получает пользователя с числом избранных и оцененных песен
+func (r *MemoryRepository) GetUser(name string) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id := r.userID(name)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get a non-existent user: '%s'\n", name))
+		return User{}, errors.New("user does not exist")
+	}
+
+	user := User{ID: id, Name: name, CreatedAt: r.users[id].createdAt}
+	for songID, s := range r.songs {
+		if s.deleted() {
+			continue
+		}
+		if r.favorites[songID][id] {
+			user.Favorites++
+		}
+		if _, ok := r.ratings[songID][id]; ok {
+			user.Ratings++
+		}
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got user '%s' successfully\n", name))
+	return user, nil
+}
+
+// Добавляет песню в избранное пользователя или убирает из него
+func (r *MemoryRepository) SetFavorite(user, song, group string, favorite bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.userID(user)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to change favorites of a non-existent user: '%s'\n", user))
+		return errors.New("user does not exist")
+	}
+	songID := r.exists(song, group)
+	if songID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to favorite a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	if favorite {
+		if r.favorites[songID] == nil {
+			r.favorites[songID] = map[int]bool{}
+		}
+		r.favorites[songID][id] = true
+	} else {
+		delete(r.favorites[songID], id)
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Favorite '%s' by '%s' of user '%s' set to %t successfully\n", song, group, user, favorite))
+	return nil
+}
+
+// Ставит песне оценку пользователя, 0 - убирает оценку. Агрегаты песни меняются на разницу
+func (r *MemoryRepository) RateSong(user, song, group string, rating int) (float64, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.userID(user)
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to rate by a non-existent user: '%s'\n", user))
+		return 0, 0, errors.New("user does not exist")
+	}
+	songID := r.exists(song, group)
+	if songID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to rate a non-existent song: '%s' by '%s'\n", song, group))
+		return 0, 0, errors.New("song does not exist")
+	}
+
+	s := r.songs[songID]
+	if old, ok := r.ratings[songID][id]; ok {
+		s.ratingCount--
+		s.ratingSum -= old
+		delete(r.ratings[songID], id)
+	}
+	if rating != 0 {
+		if r.ratings[songID] == nil {
+			r.ratings[songID] = map[int]int{}
+		}
+		r.ratings[songID][id] = rating
+		s.ratingCount++
+		s.ratingSum += rating
+	}
+	r.songs[songID] = s
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' rated %d by '%s' successfully\n", song, group, rating, user))
+	return ratingAverage(s.ratingSum, s.ratingCount), s.ratingCount, nil
+}
@@ -0,0 +1,19 @@
+package database
+
+import "time"
+
+// Участие человека в группе
+type Membership struct {
+	Person string
+	Group  string
+	// Например, "vocals" или "guitar"
+	Role string
+	// Период участия, границы включаются. Нулевая дата - период открыт с этой стороны
+	From time.Time
+	To   time.Time
+}
+
+// Проверяет, участвовал ли человек в группе в указанный день
+func (m Membership) ActiveAt(date time.Time) bool {
+	return (m.From.IsZero() || !m.From.After(date)) && (m.To.IsZero() || !m.To.Before(date))
+}
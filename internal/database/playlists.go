@@ -0,0 +1,20 @@
+package database
+
+import "time"
+
+// Плейлист. Плейлист определяется названием
+type Playlist struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+	// Число песен в плейлисте
+	Songs int
+	// Песни плейлиста по порядку, заполняются только при получении одного плейлиста
+	Entries []PlaylistEntry
+}
+
+// Песня в плейлисте. Позиции идут подряд с единицы, одна песня может стоять на нескольких позициях
+type PlaylistEntry struct {
+	Position int
+	Song     SongData
+}
@@ -0,0 +1,45 @@
+package database
+
+import "time"
+
+// Прослушивание песни пользователем
+type Play struct {
+	PublicID string
+	Song     string
+	Group    string
+	PlayedAt time.Time
+	// Сколько секунд песня проигрывалась
+	Duration int
+}
+
+// Песня в чарте. PreviousRank - место за предыдущий период, 0 - песни в нем не было
+type ChartSong struct {
+	Rank         int
+	PreviousRank int
+	PublicID     string
+	Song         string
+	Group        string
+	Plays        int64
+	Duration     int64
+}
+
+// Группа в чарте, считается по прослушиваниям всех ее песен
+type ChartGroup struct {
+	Rank         int
+	PreviousRank int
+	Group        string
+	Plays        int64
+	Duration     int64
+}
+
+// Чарт за период
+type Chart struct {
+	Songs  []ChartSong
+	Groups []ChartGroup
+}
+
+// Сутки прослушивания по UTC, по ним копятся суммы для чартов
+func playDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
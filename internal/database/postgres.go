@@ -0,0 +1,429 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"music/tools"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Хранилище песен в PostgreSQL
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+// Создает хранилище поверх общего пула соединений
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// Проверяет существование песни в БД
+func (r *PostgresRepository) Exists(song, group string) (int, error) {
+	userID := -1
+	statement := `SELECT s.song_id FROM "Song" s JOIN "Group" g ON s.group_id = g.group_id WHERE s.name = $1 AND g.name = $2 AND s.deleted_at IS NULL`
+	rows, err := r.db.Query(statement, song, group)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return -1, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			tools.Logger.Error("Failed to scan from sql.Rows: ", err)
+			return -1, err
+		}
+		userID = id
+	}
+	return userID, nil
+}
+
+// Получает данные о песни по id
+func (r *PostgresRepository) GetSong(id int) (SongData, error) {
+	data := SongData{}
+	statement := `SELECT s.public_id, s.name, g.name, "release_date", "text", "link" FROM "Song" s JOIN "Group" g on s.group_id = g.group_id WHERE song_id = $1 AND s.deleted_at IS NULL`
+	rows, err := r.db.Query(statement, id)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return data, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dateString string
+		data.ID = id
+		err = rows.Scan(&data.PublicID, &data.Song, &data.Group, &dateString, &data.Text, &data.Link)
+		if err != nil {
+			tools.Logger.Error("Failed to scan from sql.Rows: ", err)
+			return data, err
+		}
+		data.ReleaseDate, err = time.Parse("2006-01-02T15:04:05Z07:00", dateString)
+		if err != nil {
+			tools.Logger.Error("Failed to parse time: ", err)
+			return data, err
+		}
+
+	}
+	return data, nil
+}
+
+// Получает песню по публичному id
+func (r *PostgresRepository) SongByPublicID(publicID string) (SongData, error) {
+	data := SongData{}
+	statement := `
+		SELECT s.song_id, s.public_id, s.name, g.name, s.release_date, coalesce(s."text", ''), coalesce(s."link", ''),
+			s.version, s.rating_avg, s.rating_count
+		FROM "Song" s
+		JOIN "Group" g ON s.group_id = g.group_id
+		WHERE s.public_id = $1 AND s.deleted_at IS NULL`
+
+	err := r.db.QueryRow(statement, publicID).Scan(&data.ID, &data.PublicID, &data.Song, &data.Group,
+		&data.ReleaseDate, &data.Text, &data.Link, &data.Version, &data.Rating, &data.Ratings)
+	if err == sql.ErrNoRows {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get a non-existent song by id: '%s'\n", publicID))
+		return data, errors.New("song does not exist")
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return data, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got song '%s' by '%s' by id successfully\n", data.Song, data.Group))
+	return data, nil
+}
+
+// Добавляет новую песню, группа и песня создаются в одной транзакции
+func (r *PostgresRepository) AddSong(data SongData, actor Actor) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// DO UPDATE нужен, чтобы RETURNING вернул id уже существующей группы
+	statement1 := `
+		INSERT INTO "Group" (name)
+		VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING group_id`
+
+	var groupID int
+	err = tx.QueryRow(statement1, data.Group).Scan(&groupID)
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query 1: ", err)
+		return err
+	}
+
+	statement2 := `
+		INSERT INTO "Song" ("name", "release_date", "text", "link", "group_id")
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (group_id, name) WHERE deleted_at IS NULL DO NOTHING
+		RETURNING song_id`
+
+	var songID int
+	err = tx.QueryRow(statement2, data.Song, data.ReleaseDate, data.Text, data.Link, groupID).Scan(&songID)
+	if err == sql.ErrNoRows {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add an existing song: '%s' by '%s'\n", data.Song, data.Group))
+		err = errors.New("song already exists")
+		return err
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query 2: ", err)
+		return err
+	}
+
+	if data.Album != nil {
+		err = insertSongAlbum(tx, groupID, songID, *data.Album)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = insertSongLinks(tx, songID, data.Links)
+	if err != nil {
+		return err
+	}
+
+	err = recordChange(tx, songID, RevisionCreate, actor)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' added successfully\n", data.Song, data.Group))
+	return nil
+}
+
+// Перемещает песню в корзину. Окончательно песня удаляется при очистке корзины
+func (r *PostgresRepository) DeleteSong(song, group string, actor Actor) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	id, err := lockSong(tx, song, group)
+	if err != nil {
+		return err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to delete a non-existent song: '%s' by '%s'\n", song, group))
+		err = errors.New("song does not exist")
+		return err
+	}
+
+	err = trashSong(tx, id, actor)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' deleted successfully\n", song, group))
+	return nil
+}
+
+// Перемещает песню в корзину по внутреннему id
+func (r *PostgresRepository) DeleteSongByID(id int, actor Actor) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	song, err := lockSongByID(tx, id)
+	if err != nil {
+		return err
+	}
+	if song.ID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to delete a non-existent song by id: %d\n", id))
+		err = errors.New("song does not exist")
+		return err
+	}
+
+	err = trashSong(tx, id, actor)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' deleted successfully\n", song.Song, song.Group))
+	return nil
+}
+
+// Помечает заблокированную песню удаленной
+func trashSong(tx *sql.Tx, id int, actor Actor) error {
+	statement := `update "Song" set deleted_at = now(), version = version + 1 where song_id = $1`
+	_, err := tx.Exec(statement, id)
+	if err != nil {
+		tools.Logger.Error("Failed to execute UPDATE query: ", err)
+		return err
+	}
+
+	// Удаленная песня пропадает из плейлистов и после восстановления в них не возвращается
+	err = removeSongEntries(tx, id)
+	if err != nil {
+		return err
+	}
+
+	return recordChange(tx, id, RevisionDelete, actor)
+}
+
+// Обновляет информацию о песне, пустые поля остаются без изменений.
+// Если задан ID, песня ищется по нему, а не по названию и группе.
+// Если передана ожидаемая версия, а песня уже изменилась, возвращает ошибку "song was modified"
+func (r *PostgresRepository) UpdateSong(data SongData, actor Actor) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id := -1
+	if data.ID != 0 {
+		// Название и группа берутся из заблокированной строки, песню могли переименовать
+		song, err := lockSongByID(tx, data.ID)
+		if err != nil {
+			return 0, err
+		}
+		id, data.Song, data.Group = song.ID, song.Song, song.Group
+	} else {
+		id, err = lockSong(tx, data.Song, data.Group)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to update a non-existent song: '%s' by '%s'\n", data.Song, data.Group))
+		err = errors.New("song does not exist")
+		return 0, err
+	}
+
+	// Старая ссылка нужна, чтобы заменить ее в SongLink
+	var oldLink sql.NullString
+	if data.Link != "" {
+		err = tx.QueryRow(`SELECT "link" FROM "Song" WHERE song_id = $1`, id).Scan(&oldLink)
+		if err != nil {
+			tools.Logger.Error("Failed to execute SELECT query: ", err)
+			return 0, err
+		}
+	}
+
+	// Группа, в которую переносится песня, создается при необходимости
+	var groupID sql.NullInt64
+	if data.NewGroup != "" {
+		statement := `
+			INSERT INTO "Group" (name)
+			VALUES ($1)
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING group_id`
+		err = tx.QueryRow(statement, data.NewGroup).Scan(&groupID)
+		if err != nil {
+			tools.Logger.Error("Failed to execute INSERT query: ", err)
+			return 0, err
+		}
+	}
+
+	// Строка заблокирована, поэтому версия не изменится до конца транзакции.
+	// Опустевшую старую группу удаляет триггер delete_empty_group
+	releaseDate := sql.NullTime{Time: data.ReleaseDate, Valid: !data.ReleaseDate.IsZero()}
+	statement := `
+		update "Song" set
+			"release_date" = coalesce($1, "release_date"),
+			"text" = coalesce(nullif($2, ''), "text"),
+			"link" = coalesce(nullif($3, ''), "link"),
+			"name" = coalesce(nullif($6, ''), "name"),
+			"group_id" = coalesce($7, "group_id"),
+			"version" = "version" + 1
+		where "song_id" = $4 and (coalesce(cardinality($5::int[]), 0) = 0 or "version" = any($5))
+		returning "version"`
+	var version int
+	err = tx.QueryRow(statement, releaseDate, data.Text, data.Link, id, pq.Array(data.ExpectedVersions), data.NewSong, groupID).Scan(&version)
+	if err == sql.ErrNoRows {
+		tools.Logger.Info(fmt.Sprintf("Attempt to update a modified song: '%s' by '%s', expected versions %v\n", data.Song, data.Group, data.ExpectedVersions))
+		err = errors.New("song was modified")
+		return 0, err
+	}
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			tools.Logger.Info(fmt.Sprintf("Attempt to rename '%s' by '%s' to an existing song\n", data.Song, data.Group))
+			return 0, errors.New("song already exists")
+		}
+		tools.Logger.Error("Failed to execute UPDATE query: ", err)
+		return 0, err
+	}
+
+	if data.Link != "" {
+		err = replaceSongLink(tx, id, oldLink.String, data.Link)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Альбомы принадлежат старой группе, поэтому песня с них убирается
+	if data.NewGroup != "" && data.NewGroup != data.Group {
+		_, err = tx.Exec(`DELETE FROM "AlbumTrack" WHERE song_id = $1`, id)
+		if err != nil {
+			tools.Logger.Error("Failed to execute DELETE query: ", err)
+			return 0, err
+		}
+	}
+
+	err = recordChange(tx, id, RevisionUpdate, actor)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return 0, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' updated successfully\n", data.Song, data.Group))
+	return version, nil
+}
+
+// Получает текст песни
+func (r *PostgresRepository) GetText(song, group string) (string, error) {
+	id, err := r.Exists(song, group)
+	if err != nil {
+		return "", err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get text of non-existent song: '%s' by '%s'\n", song, group))
+		err = errors.New("song does not exist")
+		return "", err
+	}
+
+	data, err := r.GetSong(id)
+	if err != nil {
+		return "", err
+	}
+
+	text := data.Text
+
+	tools.Logger.Info(fmt.Sprintf("Got text of '%s' by '%s' successfully\n", song, group))
+	return text, nil
+}
+
+// Получает список песен
+func (r *PostgresRepository) ListSongs(filter SongFilter) ([]SongData, error) {
+	data := []SongData{}
+	statement, args, err := BuildListQuery(filter)
+	if err != nil {
+		tools.Logger.Error("Failed to build SELECT query: ", err)
+		return data, err
+	}
+
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query ", err)
+		return data, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		temp := SongData{}
+		dateString := ""
+		err = rows.Scan(&temp.ID, &temp.Song, &temp.Group, &dateString, &temp.Text, &temp.Link, &temp.Version, &temp.Rating, &temp.Ratings, &temp.PublicID)
+		if err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return data, err
+		}
+		temp.ReleaseDate, err = time.Parse("2006-01-02T15:04:05Z07:00", dateString)
+		if err != nil {
+			tools.Logger.Error("Failed to parse time: ", err)
+			return data, err
+		}
+		data = append(data, temp)
+
+	}
+
+	tools.Logger.Info("Got list of songs successfully")
+	return data, nil
+}
@@ -0,0 +1,332 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"music/tools"
+
+	"github.com/lib/pq"
+)
+
+// Находит альбом группы, -1 - альбома нет
+func albumID(q querier, album, group string) (int, error) {
+	statement := `
+		SELECT a.album_id
+		FROM "Album" a
+		JOIN "Group" g ON a.group_id = g.group_id
+		WHERE a.title = $1 AND g.name = $2`
+
+	id := -1
+	err := q.QueryRow(statement, album, group).Scan(&id)
+	if err == sql.ErrNoRows {
+		return -1, nil
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return -1, err
+	}
+	return id, nil
+}
+
+// Удаляет группу без песен, альбомов и участников.
+// Триггер delete_empty_group срабатывает только на удаление песен
+func deleteEmptyGroup(q querier, groupID int) error {
+	statement := `
+		DELETE FROM "Group" g
+		WHERE g.group_id = $1
+			AND NOT EXISTS (SELECT 1 FROM "Song" WHERE group_id = g.group_id)
+			AND NOT EXISTS (SELECT 1 FROM "Album" WHERE group_id = g.group_id)
+			AND NOT EXISTS (SELECT 1 FROM "Membership" WHERE group_id = g.group_id)`
+
+	_, err := q.Exec(statement, groupID)
+	if err != nil {
+		tools.Logger.Error("Failed to delete empty group: ", err)
+		return err
+	}
+	return nil
+}
+
+// Добавляет песню на альбом из данных music info API. Существующий альбом
+// не перезаписывается, а если место на альбоме уже занято, песня на него не попадает
+func insertSongAlbum(q querier, groupID, songID int, album SongAlbum) error {
+	releaseDate := sql.NullTime{Time: album.ReleaseDate, Valid: !album.ReleaseDate.IsZero()}
+	statement1 := `
+		INSERT INTO "Album" (group_id, title, release_date, album_type)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (group_id, title) DO UPDATE SET release_date = coalesce("Album".release_date, EXCLUDED.release_date)
+		RETURNING album_id`
+
+	var id int
+	err := q.QueryRow(statement1, groupID, album.Title, releaseDate, album.Type).Scan(&id)
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query: ", err)
+		return err
+	}
+
+	statement2 := `
+		INSERT INTO "AlbumTrack" (album_id, song_id, disc_number, track_number)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING`
+
+	_, err = q.Exec(statement2, id, songID, album.Disc, album.Track)
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query: ", err)
+		return err
+	}
+	return nil
+}
+
+// Получает альбомы с песнями
+func (r *PostgresRepository) ListAlbums(group, album string) ([]Album, error) {
+	albums := []Album{}
+
+	// Песни из корзины в список треков не попадают
+	statement := `
+		SELECT a.album_id, a.title, g.name, a.release_date, a.album_type, s.name, t.disc_number, t.track_number
+		FROM "Album" a
+		JOIN "Group" g ON a.group_id = g.group_id
+		LEFT JOIN ("AlbumTrack" t JOIN "Song" s ON s.song_id = t.song_id AND s.deleted_at IS NULL)
+			ON t.album_id = a.album_id
+		WHERE ($1 = '' OR g.name = $1) AND ($2 = '' OR a.title = $2)
+		ORDER BY g.name, a.release_date NULLS LAST, a.title, a.album_id, t.disc_number, t.track_number`
+
+	rows, err := r.db.Query(statement, group, album)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return albums, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		temp := Album{}
+		var releaseDate sql.NullTime
+		var song sql.NullString
+		var disc, track sql.NullInt64
+		err = rows.Scan(&temp.ID, &temp.Title, &temp.Group, &releaseDate, &temp.Type, &song, &disc, &track)
+		if err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return albums, err
+		}
+		temp.ReleaseDate = releaseDate.Time
+
+		// Строки одного альбома идут подряд
+		if len(albums) == 0 || albums[len(albums)-1].ID != temp.ID {
+			temp.Tracks = []AlbumTrack{}
+			albums = append(albums, temp)
+		}
+		if song.Valid {
+			last := &albums[len(albums)-1]
+			last.Tracks = append(last.Tracks, AlbumTrack{Song: song.String, Disc: int(disc.Int64), Track: int(track.Int64)})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return albums, err
+	}
+
+	tools.Logger.Info("Got list of albums successfully")
+	return albums, nil
+}
+
+// Добавляет новый альбом
+func (r *PostgresRepository) AddAlbum(album Album) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	statement1 := `
+		INSERT INTO "Group" (name)
+		VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING group_id`
+
+	var groupID int
+	err = tx.QueryRow(statement1, album.Group).Scan(&groupID)
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query 1: ", err)
+		return err
+	}
+
+	releaseDate := sql.NullTime{Time: album.ReleaseDate, Valid: !album.ReleaseDate.IsZero()}
+	statement2 := `
+		INSERT INTO "Album" (group_id, title, release_date, album_type)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (group_id, title) DO NOTHING
+		RETURNING album_id`
+
+	var id int
+	err = tx.QueryRow(statement2, groupID, album.Title, releaseDate, album.Type).Scan(&id)
+	if err == sql.ErrNoRows {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add an existing album: '%s' by '%s'\n", album.Title, album.Group))
+		return errors.New("album already exists")
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query 2: ", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Album '%s' by '%s' added successfully\n", album.Title, album.Group))
+	return nil
+}
+
+// Обновляет дату выхода и тип альбома, пустые поля остаются без изменений
+func (r *PostgresRepository) UpdateAlbum(album Album) error {
+	releaseDate := sql.NullTime{Time: album.ReleaseDate, Valid: !album.ReleaseDate.IsZero()}
+	statement := `
+		UPDATE "Album" a SET
+			release_date = coalesce($1, a.release_date),
+			album_type = coalesce(nullif($2, ''), a.album_type)
+		FROM "Group" g
+		WHERE a.group_id = g.group_id AND a.title = $3 AND g.name = $4`
+
+	result, err := r.db.Exec(statement, releaseDate, album.Type, album.Title, album.Group)
+	if err != nil {
+		tools.Logger.Error("Failed to execute UPDATE query: ", err)
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		tools.Logger.Error("Failed to get affected rows: ", err)
+		return err
+	}
+	if updated == 0 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to update a non-existent album: '%s' by '%s'\n", album.Title, album.Group))
+		return errors.New("album does not exist")
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Album '%s' by '%s' updated successfully\n", album.Title, album.Group))
+	return nil
+}
+
+// Удаляет альбом. Песни альбома остаются
+func (r *PostgresRepository) DeleteAlbum(album, group string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	statement1 := `
+		DELETE FROM "Album" a
+		USING "Group" g
+		WHERE a.group_id = g.group_id AND a.title = $1 AND g.name = $2
+		RETURNING a.group_id`
+
+	var groupID int
+	err = tx.QueryRow(statement1, album, group).Scan(&groupID)
+	if err == sql.ErrNoRows {
+		tools.Logger.Info(fmt.Sprintf("Attempt to delete a non-existent album: '%s' by '%s'\n", album, group))
+		return errors.New("album does not exist")
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute DELETE query 1: ", err)
+		return err
+	}
+
+	err = deleteEmptyGroup(tx, groupID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Album '%s' by '%s' deleted successfully\n", album, group))
+	return nil
+}
+
+// Добавляет песню группы на ее альбом
+func (r *PostgresRepository) AddTrack(album, group, song string, disc, track int) error {
+	id, err := albumID(r.db, album, group)
+	if err != nil {
+		return err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add a track to a non-existent album: '%s' by '%s'\n", album, group))
+		return errors.New("album does not exist")
+	}
+
+	songID, err := r.Exists(song, group)
+	if err != nil {
+		return err
+	}
+	if songID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add a non-existent song to an album: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	statement := `
+		INSERT INTO "AlbumTrack" (album_id, song_id, disc_number, track_number)
+		VALUES ($1, $2, $3, $4)`
+
+	_, err = r.db.Exec(statement, id, songID, disc, track)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			if pqErr.Constraint == "uq_album_track" {
+				tools.Logger.Info(fmt.Sprintf("Attempt to add a track to a taken place %d-%d on '%s' by '%s'\n", disc, track, album, group))
+				return errors.New("track is taken")
+			}
+			tools.Logger.Info(fmt.Sprintf("Attempt to add song '%s' to album '%s' by '%s' twice\n", song, album, group))
+			return errors.New("song is already on album")
+		}
+		tools.Logger.Error("Failed to execute INSERT query: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' added to album '%s' by '%s' successfully\n", song, album, group))
+	return nil
+}
+
+// Убирает песню с альбома
+func (r *PostgresRepository) RemoveTrack(album, group, song string) error {
+	id, err := albumID(r.db, album, group)
+	if err != nil {
+		return err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove a track from a non-existent album: '%s' by '%s'\n", album, group))
+		return errors.New("album does not exist")
+	}
+
+	songID, err := r.Exists(song, group)
+	if err != nil {
+		return err
+	}
+	if songID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove a non-existent song from an album: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	result, err := r.db.Exec(`DELETE FROM "AlbumTrack" WHERE album_id = $1 AND song_id = $2`, id, songID)
+	if err != nil {
+		tools.Logger.Error("Failed to execute DELETE query: ", err)
+		return err
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		tools.Logger.Error("Failed to get affected rows: ", err)
+		return err
+	}
+	if removed == 0 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove song '%s' that is not on album '%s' by '%s'\n", song, album, group))
+		return errors.New("song is not on album")
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' removed from album '%s' by '%s' successfully\n", song, album, group))
+	return nil
+}
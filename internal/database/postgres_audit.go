@@ -0,0 +1,97 @@
+package database
+
+import (
+	"fmt"
+	"music/tools"
+	"strings"
+)
+
+// Состояние песни из ревизии в виде JSON, совпадает с auditImage
+func revisionJSON(alias string) string {
+	return fmt.Sprintf(`jsonb_build_object('song', %[1]s.name, 'group', %[1]s.group_name,
+		'releaseDate', %[1]s.release_date, 'text', coalesce(%[1]s.text, ''), 'link', coalesce(%[1]s.link, ''))`, alias)
+}
+
+// Сохраняет ревизию песни и запись журнала аудита с состояниями до и после изменения
+func recordChange(q querier, songID int, operation string, actor Actor) error {
+	err := insertRevision(q, songID, operation)
+	if err != nil {
+		return err
+	}
+
+	// Состояние "до" - предыдущая ревизия, "после" - только что сохраненная
+	statement := fmt.Sprintf(`
+		INSERT INTO "AuditLog" (song_id, song_name, group_name, operation, actor, request_id, before, after)
+		SELECT cur.song_id, cur.name, cur.group_name, cur.operation, $2, $3,
+			CASE WHEN prev.song_id IS NOT NULL AND $4 THEN %s END,
+			CASE WHEN $5 THEN %s END
+		FROM "SongRevision" cur
+		LEFT JOIN "SongRevision" prev ON prev.song_id = cur.song_id AND prev.revision = cur.revision - 1
+		WHERE cur.song_id = $1
+		ORDER BY cur.revision DESC
+		LIMIT 1`, revisionJSON("prev"), revisionJSON("cur"))
+
+	_, err = q.Exec(statement, songID, actor.Name, actor.RequestID, auditHasBefore(operation), auditHasAfter(operation))
+	if err != nil {
+		tools.Logger.Error("Failed to save audit entry: ", err)
+		return err
+	}
+	return nil
+}
+
+// Получает записи журнала аудита, от новых к старым
+func (r *PostgresRepository) ListAudit(filter AuditFilter) ([]AuditEntry, error) {
+	entries := []AuditEntry{}
+
+	args := &queryArgs{}
+	conditions := []string{"TRUE"}
+	if filter.Song != "" {
+		conditions = append(conditions, "song_name = "+args.add(filter.Song))
+	}
+	if filter.Group != "" {
+		conditions = append(conditions, "group_name = "+args.add(filter.Group))
+	}
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = "+args.add(filter.Actor))
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= "+args.add(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < "+args.add(filter.To))
+	}
+
+	statement := fmt.Sprintf(`
+		SELECT audit_id, song_name, group_name, operation, actor, request_id, before, after, created_at
+		FROM "AuditLog"
+		WHERE %s
+		ORDER BY created_at DESC, audit_id DESC
+		LIMIT %s`, strings.Join(conditions, " AND "), args.add(filter.Limit))
+
+	rows, err := r.db.Query(statement, args.values...)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return entries, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry := AuditEntry{}
+		var before, after []byte
+		err = rows.Scan(&entry.ID, &entry.Song, &entry.Group, &entry.Operation, &entry.Actor,
+			&entry.RequestID, &before, &after, &entry.CreatedAt)
+		if err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return entries, err
+		}
+		entry.Before, entry.After = before, after
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return entries, err
+	}
+
+	tools.Logger.Info("Got audit log successfully")
+	return entries, nil
+}
@@ -0,0 +1,274 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"music/tools"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Создает недостающие метки
+func insertLabels(q querier, kind LabelKind, names []string) error {
+	tables := labelTablesOf[kind]
+	statement := fmt.Sprintf(`
+		INSERT INTO %s (name)
+		SELECT unnest($1::text[])
+		ON CONFLICT (name) DO NOTHING`, tables.table)
+
+	_, err := q.Exec(statement, pq.Array(names))
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query: ", err)
+		return err
+	}
+	return nil
+}
+
+// Получает метки песни по видам
+func (r *PostgresRepository) SongLabels(song, group string) (map[LabelKind][]string, error) {
+	labels := map[LabelKind][]string{LabelGenre: {}, LabelTag: {}}
+
+	id, err := r.Exists(song, group)
+	if err != nil {
+		return labels, err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get labels of non-existent song: '%s' by '%s'\n", song, group))
+		return labels, errors.New("song does not exist")
+	}
+
+	for kind, tables := range labelTablesOf {
+		statement := fmt.Sprintf(`
+			SELECT l.name
+			FROM %s sl
+			JOIN %s l ON l.%s = sl.%s
+			WHERE sl.song_id = $1
+			ORDER BY l.name`, tables.link, tables.table, tables.column, tables.column)
+
+		rows, err := r.db.Query(statement, id)
+		if err != nil {
+			tools.Logger.Error("Failed to execute SELECT query: ", err)
+			return labels, err
+		}
+		for rows.Next() {
+			var name string
+			if err = rows.Scan(&name); err != nil {
+				rows.Close()
+				tools.Logger.Error("Failed to scan sql.Rows: ", err)
+				return labels, err
+			}
+			labels[kind] = append(labels[kind], name)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+			return labels, err
+		}
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got labels of '%s' by '%s' successfully\n", song, group))
+	return labels, nil
+}
+
+// Добавляет песне метки всех видов в одной транзакции, недостающие метки создаются
+func (r *PostgresRepository) AttachLabels(song, group string, labels map[LabelKind][]string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	id, err := lockSong(tx, song, group)
+	if err != nil {
+		return err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to label a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	for kind, names := range labels {
+		err = insertLabels(tx, kind, names)
+		if err != nil {
+			return err
+		}
+
+		tables := labelTablesOf[kind]
+		statement := fmt.Sprintf(`
+			INSERT INTO %[1]s (song_id, %[3]s)
+			SELECT $1, l.%[3]s FROM %[2]s l WHERE l.name = ANY($2)
+			ON CONFLICT DO NOTHING`, tables.link, tables.table, tables.column)
+
+		_, err = tx.Exec(statement, id, pq.Array(names))
+		if err != nil {
+			tools.Logger.Error("Failed to execute INSERT query: ", err)
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' labeled successfully\n", song, group))
+	return nil
+}
+
+// Убирает метки песни всех видов в одной транзакции, отсутствующие метки пропускаются
+func (r *PostgresRepository) DetachLabels(song, group string, labels map[LabelKind][]string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	id, err := lockSong(tx, song, group)
+	if err != nil {
+		return err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to unlabel a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	for kind, names := range labels {
+		tables := labelTablesOf[kind]
+		statement := fmt.Sprintf(`
+			DELETE FROM %[1]s sl
+			USING %[2]s l
+			WHERE sl.%[3]s = l.%[3]s AND sl.song_id = $1 AND l.name = ANY($2)`, tables.link, tables.table, tables.column)
+
+		_, err = tx.Exec(statement, id, pq.Array(names))
+		if err != nil {
+			tools.Logger.Error("Failed to execute DELETE query: ", err)
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Labels removed from '%s' by '%s' successfully\n", song, group))
+	return nil
+}
+
+// Добавляет метки всем песням, подходящим под условия фильтра. Пагинация фильтра не учитывается.
+// Песни выбираются до добавления меток, чтобы фильтр по жанрам и тегам не зависел от порядка вставки
+func (r *PostgresRepository) LabelSongs(filter SongFilter, labels map[LabelKind][]string) (int64, error) {
+	args := &queryArgs{}
+	conditions, err := filter.whereSQL(args)
+	if err != nil {
+		tools.Logger.Error("Failed to build query: ", err)
+		return 0, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	statement1 := `
+		SELECT s.song_id
+		FROM "Song" s
+		JOIN "Group" g ON s.group_id = g.group_id
+		WHERE ` + strings.Join(conditions, " AND ")
+
+	rows, err := tx.Query(statement1, args.values...)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return 0, err
+	}
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return 0, err
+	}
+
+	for kind, names := range labels {
+		err = insertLabels(tx, kind, names)
+		if err != nil {
+			return 0, err
+		}
+
+		tables := labelTablesOf[kind]
+		statement2 := fmt.Sprintf(`
+			INSERT INTO %[1]s (song_id, %[3]s)
+			SELECT ids.song_id, l.%[3]s
+			FROM unnest($1::int[]) AS ids(song_id)
+			CROSS JOIN %[2]s l
+			WHERE l.name = ANY($2)
+			ON CONFLICT DO NOTHING`, tables.link, tables.table, tables.column)
+
+		_, err = tx.Exec(statement2, pq.Array(ids), pq.Array(names))
+		if err != nil {
+			tools.Logger.Error("Failed to execute INSERT query: ", err)
+			return 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return 0, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Labeled %d songs successfully\n", len(ids)))
+	return int64(len(ids)), nil
+}
+
+// Получает метки вида kind, от самых популярных
+func (r *PostgresRepository) ListLabels(kind LabelKind) ([]Label, error) {
+	labels := []Label{}
+
+	tables := labelTablesOf[kind]
+	statement := fmt.Sprintf(`
+		SELECT l.name, count(*)
+		FROM %[2]s l
+		JOIN %[1]s sl ON sl.%[3]s = l.%[3]s
+		JOIN "Song" s ON s.song_id = sl.song_id AND s.deleted_at IS NULL
+		GROUP BY l.name
+		ORDER BY count(*) DESC, l.name`, tables.link, tables.table, tables.column)
+
+	rows, err := r.db.Query(statement)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return labels, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		label := Label{}
+		if err = rows.Scan(&label.Name, &label.Songs); err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return labels, err
+		}
+		labels = append(labels, label)
+	}
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return labels, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got list of %s labels successfully", kind))
+	return labels, nil
+}
@@ -0,0 +1,230 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"music/tools"
+)
+
+// Добавляет ссылки новой песни, повторяющиеся ссылки пропускаются
+func insertSongLinks(tx *sql.Tx, songID int, links []Link) error {
+	statement := `
+		INSERT INTO "SongLink" (song_id, provider, url, label)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (song_id, url) DO NOTHING`
+
+	for _, link := range links {
+		_, err := tx.Exec(statement, songID, link.Provider, link.URL, link.Label)
+		if err != nil {
+			tools.Logger.Error("Failed to execute INSERT query: ", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Заменяет в SongLink основную ссылку песни old на new, остальные ссылки песни не меняются.
+// Ссылки сравниваются в каноническом виде, неподходящие ссылки пропускаются
+func replaceSongLink(tx *sql.Tx, songID int, old, new string) error {
+	_, oldURL, oldErr := NormalizeLink(old)
+	provider, newURL, newErr := NormalizeLink(new)
+	if oldErr == nil && newErr == nil && oldURL == newURL {
+		return nil
+	}
+
+	if oldErr == nil {
+		_, err := tx.Exec(`DELETE FROM "SongLink" WHERE song_id = $1 AND url = $2`, songID, oldURL)
+		if err != nil {
+			tools.Logger.Error("Failed to execute DELETE query: ", err)
+			return err
+		}
+	}
+	if newErr == nil {
+		return insertSongLinks(tx, songID, []Link{{Provider: provider, URL: newURL}})
+	}
+	return nil
+}
+
+// Приводит к каноническому виду ссылки, которые миграция перенесла из Song.link с пустым provider.
+// Разбор ссылки в SQL не совпал бы с NormalizeLink, поэтому перенос заканчивается здесь.
+// Неподходящие ссылки и ссылки, совпавшие с уже сохраненными, удаляются
+func NormalizePendingLinks(db *sql.DB) error {
+	type pendingLink struct {
+		id     int
+		songID int
+		url    string
+	}
+
+	rows, err := db.Query(`SELECT link_id, song_id, url FROM "SongLink" WHERE provider = '' ORDER BY link_id`)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return err
+	}
+	defer rows.Close()
+
+	pending := []pendingLink{}
+	for rows.Next() {
+		link := pendingLink{}
+		if err = rows.Scan(&link.id, &link.songID, &link.url); err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return err
+		}
+		pending = append(pending, link)
+	}
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	update := `
+		UPDATE "SongLink" SET provider = $2, url = $3
+		WHERE link_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM "SongLink" WHERE song_id = $4 AND url = $3 AND link_id <> $1
+		)`
+	for _, link := range pending {
+		provider, canonical, err := NormalizeLink(link.url)
+		if err == nil {
+			result, err := tx.Exec(update, link.id, provider, canonical, link.songID)
+			if err != nil {
+				tools.Logger.Error("Failed to execute UPDATE query: ", err)
+				return err
+			}
+			updated, err := result.RowsAffected()
+			if err != nil {
+				tools.Logger.Error("Failed to get affected rows: ", err)
+				return err
+			}
+			if updated == 1 {
+				continue
+			}
+		}
+		_, err = tx.Exec(`DELETE FROM "SongLink" WHERE link_id = $1`, link.id)
+		if err != nil {
+			tools.Logger.Error("Failed to execute DELETE query: ", err)
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Normalized %d links moved from songs", len(pending)))
+	return nil
+}
+
+// Получает ссылки песни по порядку добавления
+func (r *PostgresRepository) SongLinks(song, group string) ([]Link, error) {
+	links := []Link{}
+
+	id, err := r.Exists(song, group)
+	if err != nil {
+		return links, err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get links of a non-existent song: '%s' by '%s'\n", song, group))
+		return links, errors.New("song does not exist")
+	}
+
+	statement := `SELECT provider, url, label FROM "SongLink" WHERE song_id = $1 ORDER BY link_id`
+	rows, err := r.db.Query(statement, id)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return links, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		link := Link{}
+		if err = rows.Scan(&link.Provider, &link.URL, &link.Label); err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return links, err
+		}
+		links = append(links, link)
+	}
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return links, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got links of '%s' by '%s' successfully\n", song, group))
+	return links, nil
+}
+
+// Добавляет песне ссылку
+func (r *PostgresRepository) AddLink(song, group string, link Link) error {
+	id, err := r.Exists(song, group)
+	if err != nil {
+		return err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add a link to a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	statement := `
+		INSERT INTO "SongLink" (song_id, provider, url, label)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (song_id, url) DO NOTHING`
+
+	result, err := r.db.Exec(statement, id, link.Provider, link.URL, link.Label)
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query: ", err)
+		return err
+	}
+	added, err := result.RowsAffected()
+	if err != nil {
+		tools.Logger.Error("Failed to get affected rows: ", err)
+		return err
+	}
+	if added == 0 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add an existing link '%s' to '%s' by '%s'\n", link.URL, song, group))
+		return errors.New("link already exists")
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Link '%s' added to '%s' by '%s' successfully\n", link.URL, song, group))
+	return nil
+}
+
+// Убирает ссылку песни
+func (r *PostgresRepository) DeleteLink(song, group, url string) error {
+	id, err := r.Exists(song, group)
+	if err != nil {
+		return err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove a link of a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	result, err := r.db.Exec(`DELETE FROM "SongLink" WHERE song_id = $1 AND url = $2`, id, url)
+	if err != nil {
+		tools.Logger.Error("Failed to execute DELETE query: ", err)
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		tools.Logger.Error("Failed to get affected rows: ", err)
+		return err
+	}
+	if deleted == 0 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove a non-existent link '%s' of '%s' by '%s'\n", url, song, group))
+		return errors.New("link does not exist")
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Link '%s' removed from '%s' by '%s' successfully\n", url, song, group))
+	return nil
+}
@@ -0,0 +1,234 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"music/tools"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Читает периоды участия из строк person, group, role, active_from, active_to
+func scanMemberships(rows *sql.Rows) ([]Membership, error) {
+	memberships := []Membership{}
+	defer rows.Close()
+
+	for rows.Next() {
+		temp := Membership{}
+		var from, to sql.NullTime
+		err := rows.Scan(&temp.Person, &temp.Group, &temp.Role, &from, &to)
+		if err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return memberships, err
+		}
+		temp.From, temp.To = from.Time, to.Time
+		memberships = append(memberships, temp)
+	}
+	if err := rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return memberships, err
+	}
+	return memberships, nil
+}
+
+// Добавляет период участия человека в группе, человек и группа создаются при необходимости
+func (r *PostgresRepository) AddMembership(membership Membership) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// DO UPDATE нужен, чтобы RETURNING вернул id уже существующей строки
+	statement1 := `
+		INSERT INTO "Group" (name)
+		VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING group_id`
+
+	var groupID int
+	err = tx.QueryRow(statement1, membership.Group).Scan(&groupID)
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query 1: ", err)
+		return err
+	}
+
+	statement2 := `
+		INSERT INTO "Person" (name)
+		VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING person_id`
+
+	var personID int
+	err = tx.QueryRow(statement2, membership.Person).Scan(&personID)
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query 2: ", err)
+		return err
+	}
+
+	from := sql.NullTime{Time: membership.From, Valid: !membership.From.IsZero()}
+	to := sql.NullTime{Time: membership.To, Valid: !membership.To.IsZero()}
+	statement3 := `
+		INSERT INTO "Membership" (person_id, group_id, role, active_from, active_to)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err = tx.Exec(statement3, personID, groupID, membership.Role, from, to)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			tools.Logger.Info(fmt.Sprintf("Attempt to add an existing membership: '%s' in '%s' as '%s'\n", membership.Person, membership.Group, membership.Role))
+			return errors.New("membership already exists")
+		}
+		tools.Logger.Error("Failed to execute INSERT query 3: ", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Membership of '%s' in '%s' as '%s' added successfully\n", membership.Person, membership.Group, membership.Role))
+	return nil
+}
+
+// Удаляет период участия. Человек без участий и пустая группа удаляются
+func (r *PostgresRepository) DeleteMembership(person, group, role string, from time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	fromDate := sql.NullTime{Time: from, Valid: !from.IsZero()}
+	statement1 := `
+		DELETE FROM "Membership" m
+		USING "Person" p, "Group" g
+		WHERE m.person_id = p.person_id AND m.group_id = g.group_id
+			AND p.name = $1 AND g.name = $2 AND m.role = $3
+			AND ($4::date IS NULL OR m.active_from = $4)
+		RETURNING m.person_id, m.group_id`
+
+	rows, err := tx.Query(statement1, person, group, role, fromDate)
+	if err != nil {
+		tools.Logger.Error("Failed to execute DELETE query 1: ", err)
+		return err
+	}
+	var personID, groupID int
+	deleted := 0
+	for rows.Next() {
+		err = rows.Scan(&personID, &groupID)
+		if err != nil {
+			rows.Close()
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return err
+		}
+		deleted++
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return err
+	}
+	if deleted == 0 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to delete a non-existent membership: '%s' in '%s' as '%s'\n", person, group, role))
+		return errors.New("membership does not exist")
+	}
+
+	statement2 := `
+		DELETE FROM "Person" p
+		WHERE p.person_id = $1 AND NOT EXISTS (SELECT 1 FROM "Membership" WHERE person_id = p.person_id)`
+
+	_, err = tx.Exec(statement2, personID)
+	if err != nil {
+		tools.Logger.Error("Failed to execute DELETE query 2: ", err)
+		return err
+	}
+
+	err = deleteEmptyGroup(tx, groupID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Membership of '%s' in '%s' as '%s' deleted successfully\n", person, group, role))
+	return nil
+}
+
+// Получает участников группы в указанный день
+func (r *PostgresRepository) ListLineup(group string, date time.Time) ([]Membership, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM "Group" WHERE name = $1)`, group).Scan(&exists)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return []Membership{}, err
+	}
+	if !exists {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get lineup of a non-existent group: '%s'\n", group))
+		return []Membership{}, errors.New("group does not exist")
+	}
+
+	statement := `
+		SELECT p.name, g.name, m.role, m.active_from, m.active_to
+		FROM "Membership" m
+		JOIN "Person" p ON p.person_id = m.person_id
+		JOIN "Group" g ON g.group_id = m.group_id
+		WHERE g.name = $1
+			AND (m.active_from IS NULL OR m.active_from <= $2)
+			AND (m.active_to IS NULL OR m.active_to >= $2)
+		ORDER BY p.name, m.role`
+
+	rows, err := r.db.Query(statement, group, date.Format("2006-01-02"))
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return []Membership{}, err
+	}
+
+	lineup, err := scanMemberships(rows)
+	if err != nil {
+		return lineup, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got lineup of '%s' successfully\n", group))
+	return lineup, nil
+}
+
+// Получает все периоды участия человека в группах
+func (r *PostgresRepository) ListMemberships(person string) ([]Membership, error) {
+	statement := `
+		SELECT p.name, g.name, m.role, m.active_from, m.active_to
+		FROM "Membership" m
+		JOIN "Person" p ON p.person_id = m.person_id
+		JOIN "Group" g ON g.group_id = m.group_id
+		WHERE p.name = $1
+		ORDER BY m.active_from NULLS FIRST, g.name, m.role`
+
+	rows, err := r.db.Query(statement, person)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return []Membership{}, err
+	}
+
+	memberships, err := scanMemberships(rows)
+	if err != nil {
+		return memberships, err
+	}
+	// Человек существует, только пока у него есть хотя бы одно участие
+	if len(memberships) == 0 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get groups of a non-existent person: '%s'\n", person))
+		return memberships, errors.New("person does not exist")
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got groups of '%s' successfully\n", person))
+	return memberships, nil
+}
@@ -0,0 +1,421 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"music/tools"
+
+	"github.com/lib/pq"
+)
+
+// Блокирует плейлист до конца транзакции, -1 - плейлиста нет.
+// Все изменения позиций идут под этой блокировкой
+func lockPlaylist(tx *sql.Tx, name string) (int, error) {
+	statement := `SELECT playlist_id FROM "Playlist" WHERE name = $1 FOR UPDATE`
+
+	id := -1
+	err := tx.QueryRow(statement, name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return -1, nil
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return -1, err
+	}
+	return id, nil
+}
+
+// Число песен в плейлисте
+func countEntries(q querier, playlistID int) (int, error) {
+	var count int
+	err := q.QueryRow(`SELECT count(*) FROM "PlaylistEntry" WHERE playlist_id = $1`, playlistID).Scan(&count)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// Убирает песню из всех плейлистов и закрывает образовавшиеся промежутки в позициях.
+// Вызывается при удалении песни, строка песни уже заблокирована
+func removeSongEntries(tx *sql.Tx, songID int) error {
+	statement1 := `
+		SELECT playlist_id
+		FROM "Playlist"
+		WHERE playlist_id IN (SELECT playlist_id FROM "PlaylistEntry" WHERE song_id = $1)
+		ORDER BY playlist_id
+		FOR UPDATE`
+
+	rows, err := tx.Query(statement1, songID)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return err
+	}
+	playlists := []int64{}
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return err
+		}
+		playlists = append(playlists, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return err
+	}
+	if len(playlists) == 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(`DELETE FROM "PlaylistEntry" WHERE song_id = $1`, songID)
+	if err != nil {
+		tools.Logger.Error("Failed to execute DELETE query: ", err)
+		return err
+	}
+
+	statement2 := `
+		UPDATE "PlaylistEntry" e SET position = n.position
+		FROM (
+			SELECT entry_id, row_number() OVER (PARTITION BY playlist_id ORDER BY position) AS position
+			FROM "PlaylistEntry"
+			WHERE playlist_id = ANY($1)
+		) n
+		WHERE e.entry_id = n.entry_id AND e.position <> n.position`
+
+	_, err = tx.Exec(statement2, pq.Array(playlists))
+	if err != nil {
+		tools.Logger.Error("Failed to execute UPDATE query: ", err)
+		return err
+	}
+	return nil
+}
+
+// Получает плейлисты с числом песен
+func (r *PostgresRepository) ListPlaylists() ([]Playlist, error) {
+	playlists := []Playlist{}
+
+	statement := `
+		SELECT p.playlist_id, p.name, p.created_at, count(e.entry_id)
+		FROM "Playlist" p
+		LEFT JOIN "PlaylistEntry" e ON e.playlist_id = p.playlist_id
+		GROUP BY p.playlist_id
+		ORDER BY p.name`
+
+	rows, err := r.db.Query(statement)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return playlists, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		temp := Playlist{}
+		if err = rows.Scan(&temp.ID, &temp.Name, &temp.CreatedAt, &temp.Songs); err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return playlists, err
+		}
+		playlists = append(playlists, temp)
+	}
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return playlists, err
+	}
+
+	tools.Logger.Info("Got list of playlists successfully")
+	return playlists, nil
+}
+
+// Получает плейлист с песнями по порядку
+func (r *PostgresRepository) GetPlaylist(name string) (Playlist, error) {
+	playlist := Playlist{Entries: []PlaylistEntry{}}
+
+	statement1 := `SELECT playlist_id, name, created_at FROM "Playlist" WHERE name = $1`
+	err := r.db.QueryRow(statement1, name).Scan(&playlist.ID, &playlist.Name, &playlist.CreatedAt)
+	if err == sql.ErrNoRows {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get a non-existent playlist: '%s'\n", name))
+		return playlist, errors.New("playlist does not exist")
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query 1: ", err)
+		return playlist, err
+	}
+
+	statement2 := `
+		SELECT e.position, s.song_id, s.public_id, s.name, g.name, s.release_date, s.text, s.link, s.version
+		FROM "PlaylistEntry" e
+		JOIN "Song" s ON s.song_id = e.song_id
+		JOIN "Group" g ON s.group_id = g.group_id
+		WHERE e.playlist_id = $1
+		ORDER BY e.position`
+
+	rows, err := r.db.Query(statement2, playlist.ID)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query 2: ", err)
+		return playlist, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry := PlaylistEntry{}
+		song := &entry.Song
+		err = rows.Scan(&entry.Position, &song.ID, &song.PublicID, &song.Song, &song.Group, &song.ReleaseDate, &song.Text, &song.Link, &song.Version)
+		if err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return playlist, err
+		}
+		playlist.Entries = append(playlist.Entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return playlist, err
+	}
+	playlist.Songs = len(playlist.Entries)
+
+	tools.Logger.Info(fmt.Sprintf("Got playlist '%s' successfully\n", name))
+	return playlist, nil
+}
+
+// Создает пустой плейлист
+func (r *PostgresRepository) AddPlaylist(name string) error {
+	statement := `INSERT INTO "Playlist" (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`
+
+	result, err := r.db.Exec(statement, name)
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query: ", err)
+		return err
+	}
+	added, err := result.RowsAffected()
+	if err != nil {
+		tools.Logger.Error("Failed to get affected rows: ", err)
+		return err
+	}
+	if added == 0 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add an existing playlist: '%s'\n", name))
+		return errors.New("playlist already exists")
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Playlist '%s' added successfully\n", name))
+	return nil
+}
+
+// Переименовывает плейлист
+func (r *PostgresRepository) RenamePlaylist(name, newName string) error {
+	statement := `UPDATE "Playlist" SET name = $1 WHERE name = $2`
+
+	result, err := r.db.Exec(statement, newName, name)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			tools.Logger.Info(fmt.Sprintf("Attempt to rename playlist '%s' to an existing name '%s'\n", name, newName))
+			return errors.New("playlist already exists")
+		}
+		tools.Logger.Error("Failed to execute UPDATE query: ", err)
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		tools.Logger.Error("Failed to get affected rows: ", err)
+		return err
+	}
+	if updated == 0 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to rename a non-existent playlist: '%s'\n", name))
+		return errors.New("playlist does not exist")
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Playlist '%s' renamed to '%s' successfully\n", name, newName))
+	return nil
+}
+
+// Удаляет плейлист вместе с его позициями
+func (r *PostgresRepository) DeletePlaylist(name string) error {
+	result, err := r.db.Exec(`DELETE FROM "Playlist" WHERE name = $1`, name)
+	if err != nil {
+		tools.Logger.Error("Failed to execute DELETE query: ", err)
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		tools.Logger.Error("Failed to get affected rows: ", err)
+		return err
+	}
+	if deleted == 0 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to delete a non-existent playlist: '%s'\n", name))
+		return errors.New("playlist does not exist")
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Playlist '%s' deleted successfully\n", name))
+	return nil
+}
+
+// Ставит песню в плейлист на позицию position, 0 - в конец
+func (r *PostgresRepository) AddEntry(playlist, song, group string, position int) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Песня блокируется раньше плейлиста, как и при удалении песни
+	songID, err := lockSong(tx, song, group)
+	if err != nil {
+		return 0, err
+	}
+	if songID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add a non-existent song to a playlist: '%s' by '%s'\n", song, group))
+		return 0, errors.New("song does not exist")
+	}
+
+	id, err := lockPlaylist(tx, playlist)
+	if err != nil {
+		return 0, err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add a song to a non-existent playlist: '%s'\n", playlist))
+		return 0, errors.New("playlist does not exist")
+	}
+
+	count, err := countEntries(tx, id)
+	if err != nil {
+		return 0, err
+	}
+	if position == 0 {
+		position = count + 1
+	}
+	if position > count+1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add a song to playlist '%s' at position %d of %d\n", playlist, position, count))
+		return 0, errors.New("position out of range")
+	}
+
+	statement1 := `UPDATE "PlaylistEntry" SET position = position + 1 WHERE playlist_id = $1 AND position >= $2`
+	_, err = tx.Exec(statement1, id, position)
+	if err != nil {
+		tools.Logger.Error("Failed to execute UPDATE query: ", err)
+		return 0, err
+	}
+
+	statement2 := `INSERT INTO "PlaylistEntry" (playlist_id, song_id, position) VALUES ($1, $2, $3)`
+	_, err = tx.Exec(statement2, id, songID, position)
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query: ", err)
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return 0, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' added to playlist '%s' at position %d successfully\n", song, group, playlist, position))
+	return position, nil
+}
+
+// Убирает песню с позиции плейлиста
+func (r *PostgresRepository) RemoveEntry(playlist string, position int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	id, err := lockPlaylist(tx, playlist)
+	if err != nil {
+		return err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove a song from a non-existent playlist: '%s'\n", playlist))
+		return errors.New("playlist does not exist")
+	}
+
+	result, err := tx.Exec(`DELETE FROM "PlaylistEntry" WHERE playlist_id = $1 AND position = $2`, id, position)
+	if err != nil {
+		tools.Logger.Error("Failed to execute DELETE query: ", err)
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		tools.Logger.Error("Failed to get affected rows: ", err)
+		return err
+	}
+	if deleted == 0 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to remove a non-existent entry %d from playlist '%s'\n", position, playlist))
+		return errors.New("entry does not exist")
+	}
+
+	statement := `UPDATE "PlaylistEntry" SET position = position - 1 WHERE playlist_id = $1 AND position > $2`
+	_, err = tx.Exec(statement, id, position)
+	if err != nil {
+		tools.Logger.Error("Failed to execute UPDATE query: ", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Entry %d removed from playlist '%s' successfully\n", position, playlist))
+	return nil
+}
+
+// Переставляет песню с позиции from на позицию to, песни между ними сдвигаются на одну позицию
+func (r *PostgresRepository) MoveEntry(playlist string, from, to int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	id, err := lockPlaylist(tx, playlist)
+	if err != nil {
+		return err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to move a song in a non-existent playlist: '%s'\n", playlist))
+		return errors.New("playlist does not exist")
+	}
+
+	count, err := countEntries(tx, id)
+	if err != nil {
+		return err
+	}
+	if from > count {
+		tools.Logger.Info(fmt.Sprintf("Attempt to move a non-existent entry %d in playlist '%s'\n", from, playlist))
+		return errors.New("entry does not exist")
+	}
+	if to > count {
+		tools.Logger.Info(fmt.Sprintf("Attempt to move an entry of playlist '%s' to position %d of %d\n", playlist, to, count))
+		return errors.New("position out of range")
+	}
+
+	statement := `
+		UPDATE "PlaylistEntry" SET position = CASE
+			WHEN position = $2::int THEN $3::int
+			WHEN $2::int < $3::int THEN position - 1
+			ELSE position + 1
+		END
+		WHERE playlist_id = $1 AND position BETWEEN least($2::int, $3::int) AND greatest($2::int, $3::int)`
+
+	_, err = tx.Exec(statement, id, from, to)
+	if err != nil {
+		tools.Logger.Error("Failed to execute UPDATE query: ", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Entry %d of playlist '%s' moved to position %d successfully\n", from, playlist, to))
+	return nil
+}
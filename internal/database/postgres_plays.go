@@ -0,0 +1,194 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"music/tools"
+	"time"
+)
+
+// Записывает прослушивание песни пользователем. Суточные суммы обновляет триггер rollup_play
+func (r *PostgresRepository) AddPlay(user, song, group string, playedAt time.Time, duration int) error {
+	id, err := userID(r.db, user)
+	if err != nil {
+		return err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to record a play of a non-existent user: '%s'\n", user))
+		return errors.New("user does not exist")
+	}
+
+	songID, err := r.Exists(song, group)
+	if err != nil {
+		return err
+	}
+	if songID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to play a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	statement := `INSERT INTO "Play" (song_id, user_id, played_at, duration) VALUES ($1, $2, $3, $4)`
+	_, err = r.db.Exec(statement, songID, id, playedAt, duration)
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Play of '%s' by '%s' by user '%s' recorded successfully\n", song, group, user))
+	return nil
+}
+
+// Получает последние прослушивания пользователя, новые первыми
+func (r *PostgresRepository) ListPlays(user string, limit int) ([]Play, error) {
+	plays := []Play{}
+
+	id, err := userID(r.db, user)
+	if err != nil {
+		return plays, err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get plays of a non-existent user: '%s'\n", user))
+		return plays, errors.New("user does not exist")
+	}
+
+	statement := `
+		SELECT s.public_id, s.name, g.name, p.played_at, p.duration
+		FROM "Play" p
+		JOIN "Song" s ON s.song_id = p.song_id AND s.deleted_at IS NULL
+		JOIN "Group" g ON g.group_id = s.group_id
+		WHERE p.user_id = $1
+		ORDER BY p.played_at DESC, p.play_id DESC
+		LIMIT $2`
+
+	rows, err := r.db.Query(statement, id, limit)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return plays, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		play := Play{}
+		if err = rows.Scan(&play.PublicID, &play.Song, &play.Group, &play.PlayedAt, &play.Duration); err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return plays, err
+		}
+		plays = append(plays, play)
+	}
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return plays, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got plays of user '%s' successfully\n", user))
+	return plays, nil
+}
+
+// Строит чарт по таблице PlayDaily, сами прослушивания не читаются.
+// Места за предыдущий период считаются среди всех песен и групп, а не только попавших в чарт
+func (r *PostgresRepository) Chart(period, previous DateRange, limit int) (Chart, error) {
+	chart := Chart{Songs: []ChartSong{}, Groups: []ChartGroup{}}
+
+	// Дни передаются строками, чтобы сравнение с DATE не зависело от часового пояса сессии
+	args := []any{
+		period.From.Format(time.DateOnly), period.To.Format(time.DateOnly),
+		previous.From.Format(time.DateOnly), previous.To.Format(time.DateOnly),
+		limit,
+	}
+
+	statement1 := `
+		WITH ranked AS (
+			SELECT d.song_id, sum(d.plays) AS plays, sum(d.duration) AS duration,
+				row_number() OVER (ORDER BY sum(d.plays) DESC, sum(d.duration) DESC, s.name, g.name) AS rank
+			FROM "PlayDaily" d
+			JOIN "Song" s ON s.song_id = d.song_id AND s.deleted_at IS NULL
+			JOIN "Group" g ON g.group_id = s.group_id
+			WHERE d.day >= $1::date AND d.day < $2::date
+			GROUP BY d.song_id, s.name, g.name
+		), previous AS (
+			SELECT d.song_id,
+				row_number() OVER (ORDER BY sum(d.plays) DESC, sum(d.duration) DESC, s.name, g.name) AS rank
+			FROM "PlayDaily" d
+			JOIN "Song" s ON s.song_id = d.song_id AND s.deleted_at IS NULL
+			JOIN "Group" g ON g.group_id = s.group_id
+			WHERE d.day >= $3::date AND d.day < $4::date
+			GROUP BY d.song_id, s.name, g.name
+		)
+		SELECT c.rank, COALESCE(p.rank, 0), s.public_id, s.name, g.name, c.plays, c.duration
+		FROM ranked c
+		JOIN "Song" s ON s.song_id = c.song_id
+		JOIN "Group" g ON g.group_id = s.group_id
+		LEFT JOIN previous p ON p.song_id = c.song_id
+		WHERE c.rank <= $5
+		ORDER BY c.rank`
+
+	rows, err := r.db.Query(statement1, args...)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return chart, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		song := ChartSong{}
+		err = rows.Scan(&song.Rank, &song.PreviousRank, &song.PublicID, &song.Song, &song.Group, &song.Plays, &song.Duration)
+		if err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return chart, err
+		}
+		chart.Songs = append(chart.Songs, song)
+	}
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return chart, err
+	}
+
+	statement2 := `
+		WITH ranked AS (
+			SELECT s.group_id, sum(d.plays) AS plays, sum(d.duration) AS duration,
+				row_number() OVER (ORDER BY sum(d.plays) DESC, sum(d.duration) DESC, g.name) AS rank
+			FROM "PlayDaily" d
+			JOIN "Song" s ON s.song_id = d.song_id AND s.deleted_at IS NULL
+			JOIN "Group" g ON g.group_id = s.group_id
+			WHERE d.day >= $1::date AND d.day < $2::date
+			GROUP BY s.group_id, g.name
+		), previous AS (
+			SELECT s.group_id,
+				row_number() OVER (ORDER BY sum(d.plays) DESC, sum(d.duration) DESC, g.name) AS rank
+			FROM "PlayDaily" d
+			JOIN "Song" s ON s.song_id = d.song_id AND s.deleted_at IS NULL
+			JOIN "Group" g ON g.group_id = s.group_id
+			WHERE d.day >= $3::date AND d.day < $4::date
+			GROUP BY s.group_id, g.name
+		)
+		SELECT c.rank, COALESCE(p.rank, 0), g.name, c.plays, c.duration
+		FROM ranked c
+		JOIN "Group" g ON g.group_id = c.group_id
+		LEFT JOIN previous p ON p.group_id = c.group_id
+		WHERE c.rank <= $5
+		ORDER BY c.rank`
+
+	groupRows, err := r.db.Query(statement2, args...)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return chart, err
+	}
+	defer groupRows.Close()
+
+	for groupRows.Next() {
+		group := ChartGroup{}
+		err = groupRows.Scan(&group.Rank, &group.PreviousRank, &group.Group, &group.Plays, &group.Duration)
+		if err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return chart, err
+		}
+		chart.Groups = append(chart.Groups, group)
+	}
+	if err = groupRows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return chart, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got chart from %s to %s successfully\n", period.From.Format(time.DateOnly), period.To.Format(time.DateOnly)))
+	return chart, nil
+}
@@ -0,0 +1,218 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"music/tools"
+)
+
+// Общий интерфейс *sql.DB и *sql.Tx
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// Находит неудаленную песню и блокирует ее до конца транзакции, -1 - песни нет
+func lockSong(tx *sql.Tx, song, group string) (int, error) {
+	statement := `
+		SELECT s.song_id
+		FROM "Song" s
+		JOIN "Group" g ON s.group_id = g.group_id
+		WHERE s.name = $1 AND g.name = $2 AND s.deleted_at IS NULL
+		FOR UPDATE OF s`
+
+	id := -1
+	err := tx.QueryRow(statement, song, group).Scan(&id)
+	if err == sql.ErrNoRows {
+		return -1, nil
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return -1, err
+	}
+	return id, nil
+}
+
+// Блокирует неудаленную песню по id до конца транзакции и возвращает ее название и группу, ID -1 - песни нет
+func lockSongByID(tx *sql.Tx, id int) (SongData, error) {
+	statement := `
+		SELECT s.song_id, s.name, g.name
+		FROM "Song" s
+		JOIN "Group" g ON s.group_id = g.group_id
+		WHERE s.song_id = $1 AND s.deleted_at IS NULL
+		FOR UPDATE OF s`
+
+	data := SongData{ID: -1}
+	err := tx.QueryRow(statement, id).Scan(&data.ID, &data.Song, &data.Group)
+	if err == sql.ErrNoRows {
+		return SongData{ID: -1}, nil
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return SongData{ID: -1}, err
+	}
+	return data, nil
+}
+
+// Сохраняет текущее состояние песни как новую ревизию
+func insertRevision(q querier, songID int, operation string) error {
+	statement := `
+		INSERT INTO "SongRevision" (song_id, revision, operation, name, group_name, release_date, text, link)
+		SELECT s.song_id,
+			coalesce((SELECT max(revision) FROM "SongRevision" WHERE song_id = s.song_id), 0) + 1,
+			$2, s.name, g.name, s.release_date, s."text", s."link"
+		FROM "Song" s
+		JOIN "Group" g ON s.group_id = g.group_id
+		WHERE s.song_id = $1`
+
+	_, err := q.Exec(statement, songID, operation)
+	if err != nil {
+		tools.Logger.Error("Failed to save song revision: ", err)
+		return err
+	}
+	return nil
+}
+
+// Получает ревизии песни, от первой к последней
+func (r *PostgresRepository) ListRevisions(song, group string) ([]SongRevision, error) {
+	revisions := []SongRevision{}
+
+	id, err := r.Exists(song, group)
+	if err != nil {
+		return revisions, err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get revisions of non-existent song: '%s' by '%s'\n", song, group))
+		return revisions, errors.New("song does not exist")
+	}
+
+	statement := `
+		SELECT revision, operation, name, group_name, release_date, coalesce(text, ''), coalesce(link, ''), created_at
+		FROM "SongRevision"
+		WHERE song_id = $1
+		ORDER BY revision`
+
+	rows, err := r.db.Query(statement, id)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return revisions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		revision := SongRevision{}
+		revision.ID = id
+		err = rows.Scan(&revision.Revision, &revision.Operation, &revision.Song, &revision.Group,
+			&revision.ReleaseDate, &revision.Text, &revision.Link, &revision.CreatedAt)
+		if err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return revisions, err
+		}
+		revisions = append(revisions, revision)
+	}
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return revisions, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got revisions of '%s' by '%s' successfully\n", song, group))
+	return revisions, nil
+}
+
+// Получает одну ревизию песни
+func (r *PostgresRepository) GetRevision(song, group string, revision int) (SongRevision, error) {
+	result := SongRevision{}
+
+	id, err := r.Exists(song, group)
+	if err != nil {
+		return result, err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get revision of non-existent song: '%s' by '%s'\n", song, group))
+		return result, errors.New("song does not exist")
+	}
+
+	statement := `
+		SELECT revision, operation, name, group_name, release_date, coalesce(text, ''), coalesce(link, ''), created_at
+		FROM "SongRevision"
+		WHERE song_id = $1 AND revision = $2`
+
+	result.ID = id
+	err = r.db.QueryRow(statement, id, revision).Scan(&result.Revision, &result.Operation, &result.Song, &result.Group,
+		&result.ReleaseDate, &result.Text, &result.Link, &result.CreatedAt)
+	if err == sql.ErrNoRows {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get non-existent revision %d of '%s' by '%s'\n", revision, song, group))
+		return result, errors.New("revision does not exist")
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return result, err
+	}
+
+	return result, nil
+}
+
+// Возвращает дату выхода, текст и ссылку песни к состоянию из ревизии
+func (r *PostgresRepository) RevertSong(song, group string, revision int, actor Actor) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	id, err := lockSong(tx, song, group)
+	if err != nil {
+		return err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to revert a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	var oldLink sql.NullString
+	err = tx.QueryRow(`SELECT "link" FROM "Song" WHERE song_id = $1`, id).Scan(&oldLink)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return err
+	}
+
+	statement := `
+		UPDATE "Song" s
+		SET release_date = r.release_date, "text" = r.text, "link" = r.link, version = s.version + 1
+		FROM "SongRevision" r
+		WHERE s.song_id = $1 AND r.song_id = s.song_id AND r.revision = $2
+		RETURNING s."link"`
+
+	var newLink sql.NullString
+	err = tx.QueryRow(statement, id, revision).Scan(&newLink)
+	if err == sql.ErrNoRows {
+		tools.Logger.Info(fmt.Sprintf("Attempt to revert to non-existent revision %d of '%s' by '%s'\n", revision, song, group))
+		return errors.New("revision does not exist")
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute UPDATE query: ", err)
+		return err
+	}
+
+	err = replaceSongLink(tx, id, oldLink.String, newLink.String)
+	if err != nil {
+		return err
+	}
+
+	err = recordChange(tx, id, RevisionRevert, actor)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' reverted to revision %d successfully\n", song, group, revision))
+	return nil
+}
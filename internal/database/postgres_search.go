@@ -0,0 +1,55 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"music/tools"
+)
+
+// Ищет песни по тексту, результаты отсортированы по релевантности
+func (r *PostgresRepository) SearchText(query SearchQuery) ([]SearchResult, error) {
+	results := []SearchResult{}
+
+	column, ok := searchColumns[query.Language]
+	if !ok {
+		return results, errors.New("unsupported language")
+	}
+
+	// Имя колонки берется только из белого списка searchColumns
+	statement := fmt.Sprintf(`
+		SELECT s.public_id, s.name, g.name, s.release_date,
+			ts_rank(s.%[1]s, q) AS rank,
+			ts_headline($1::regconfig, translate(coalesce(s."text", ''), $5, ''), q, $3)
+		FROM "Song" s
+		JOIN "Group" g ON s.group_id = g.group_id,
+			websearch_to_tsquery($1::regconfig, $2) q
+		WHERE s.%[1]s @@ q AND s.deleted_at IS NULL
+		ORDER BY rank DESC, s.song_id
+		LIMIT $4`, column)
+	options := fmt.Sprintf("StartSel=\"%s\", StopSel=\"%s\", MaxFragments=3, FragmentDelimiter=\" ... \"", matchStart, matchStop)
+
+	rows, err := r.db.Query(statement, query.Language, query.Text, options, query.Limit, matchStart+matchStop)
+	if err != nil {
+		tools.Logger.Error("Failed to execute search query: ", err)
+		return results, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		result := SearchResult{}
+		err = rows.Scan(&result.PublicID, &result.Song, &result.Group, &result.ReleaseDate, &result.Rank, &result.Snippet)
+		if err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return results, err
+		}
+		result.Snippet = escapeSnippet(result.Snippet)
+		results = append(results, result)
+	}
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return results, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Found %d songs by text '%s'", len(results), query.Text))
+	return results, nil
+}
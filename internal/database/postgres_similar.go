@@ -0,0 +1,43 @@
+package database
+
+import (
+	"fmt"
+	"music/tools"
+)
+
+// Ищет песни, похожие по названию и группе на искомую
+func (r *PostgresRepository) SimilarSongs(song, group string, limit int) ([]SongCandidate, error) {
+	candidates := []SongCandidate{}
+
+	statement := `
+		SELECT s.name, g.name, (similarity(s.name, $1) + similarity(g.name, $2)) / 2 AS score
+		FROM "Song" s
+		JOIN "Group" g ON s.group_id = g.group_id
+		WHERE (s.name % $1 OR g.name % $2) AND s.deleted_at IS NULL
+		ORDER BY score DESC, s.song_id
+		LIMIT $3`
+
+	rows, err := r.db.Query(statement, song, group, limit)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return candidates, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		candidate := SongCandidate{}
+		err = rows.Scan(&candidate.Song, &candidate.Group, &candidate.Similarity)
+		if err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return candidates, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return candidates, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Found %d songs similar to '%s' by '%s'", len(candidates), song, group))
+	return candidates, nil
+}
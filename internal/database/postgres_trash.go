@@ -0,0 +1,122 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"music/tools"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Код ошибки PostgreSQL "нарушение уникальности"
+const uniqueViolation = "23505"
+
+// Получает песни из корзины, сначала удаленные последними
+func (r *PostgresRepository) ListTrash() ([]TrashedSong, error) {
+	songs := []TrashedSong{}
+
+	statement := `
+		SELECT s.song_id, s.public_id, s.name, g.name, s.release_date, coalesce(s."text", ''), coalesce(s."link", ''), s.deleted_at
+		FROM "Song" s
+		JOIN "Group" g ON s.group_id = g.group_id
+		WHERE s.deleted_at IS NOT NULL
+		ORDER BY s.deleted_at DESC, s.song_id DESC`
+
+	rows, err := r.db.Query(statement)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return songs, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		song := TrashedSong{}
+		err = rows.Scan(&song.ID, &song.PublicID, &song.Song, &song.Group, &song.ReleaseDate, &song.Text, &song.Link, &song.DeletedAt)
+		if err != nil {
+			tools.Logger.Error("Failed to scan sql.Rows: ", err)
+			return songs, err
+		}
+		songs = append(songs, song)
+	}
+	if err = rows.Err(); err != nil {
+		tools.Logger.Error("Failed to iterate sql.Rows: ", err)
+		return songs, err
+	}
+
+	tools.Logger.Info("Got list of trashed songs successfully")
+	return songs, nil
+}
+
+// Восстанавливает из корзины последнюю удаленную версию песни
+func (r *PostgresRepository) RestoreSong(song, group string, actor Actor) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	statement := `
+		UPDATE "Song" SET deleted_at = NULL, version = version + 1
+		WHERE song_id = (
+			SELECT s.song_id
+			FROM "Song" s
+			JOIN "Group" g ON s.group_id = g.group_id
+			WHERE s.name = $1 AND g.name = $2 AND s.deleted_at IS NOT NULL
+			ORDER BY s.deleted_at DESC
+			LIMIT 1
+		)
+		RETURNING song_id`
+
+	var id int
+	err = tx.QueryRow(statement, song, group).Scan(&id)
+	if err == sql.ErrNoRows {
+		tools.Logger.Info(fmt.Sprintf("Attempt to restore a song that is not in trash: '%s' by '%s'\n", song, group))
+		return errors.New("song is not in trash")
+	}
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			tools.Logger.Info(fmt.Sprintf("Attempt to restore a song that already exists: '%s' by '%s'\n", song, group))
+			return errors.New("song already exists")
+		}
+		tools.Logger.Error("Failed to execute UPDATE query: ", err)
+		return err
+	}
+
+	err = recordChange(tx, id, RevisionRestore, actor)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' restored successfully\n", song, group))
+	return nil
+}
+
+// Окончательно удаляет песни, попавшие в корзину раньше указанного времени.
+// Опустевшие группы удаляет триггер delete_empty_group
+func (r *PostgresRepository) PurgeTrash(before time.Time) (int64, error) {
+	statement := `DELETE FROM "Song" WHERE deleted_at < $1`
+	result, err := r.db.Exec(statement, before)
+	if err != nil {
+		tools.Logger.Error("Failed to execute DELETE query: ", err)
+		return 0, err
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		tools.Logger.Error("Failed to get affected rows: ", err)
+		return 0, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Purged %d songs from trash", purged))
+	return purged, nil
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"music/tools"
+)
+
+// Находит пользователя, -1 - пользователя нет
+func userID(q querier, name string) (int, error) {
+	id := -1
+	err := q.QueryRow(`SELECT user_id FROM "User" WHERE name = $1`, name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return -1, nil
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return -1, err
+	}
+	return id, nil
+}
+
+// Регистрирует пользователя
+func (r *PostgresRepository) AddUser(name string) error {
+	result, err := r.db.Exec(`INSERT INTO "User" (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`, name)
+	if err != nil {
+		tools.Logger.Error("Failed to execute INSERT query: ", err)
+		return err
+	}
+	added, err := result.RowsAffected()
+	if err != nil {
+		tools.Logger.Error("Failed to get affected rows: ", err)
+		return err
+	}
+	if added == 0 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to add an existing user: '%s'\n", name))
+		return errors.New("user already exists")
+	}
+
+	tools.Logger.Info(fmt.Sprintf("User '%s' added successfully\n", name))
+	return nil
+}
+
+// Получает пользователя с числом избранных и оцененных песен
+func (r *PostgresRepository) GetUser(name string) (User, error) {
+	user := User{}
+
+	statement := `
+		SELECT u.user_id, u.name, u.created_at,
+			(SELECT count(*) FROM "Favorite" f JOIN "Song" s ON s.song_id = f.song_id
+				WHERE f.user_id = u.user_id AND s.deleted_at IS NULL),
+			(SELECT count(*) FROM "Rating" rt JOIN "Song" s ON s.song_id = rt.song_id
+				WHERE rt.user_id = u.user_id AND s.deleted_at IS NULL)
+		FROM "User" u
+		WHERE u.name = $1`
+
+	err := r.db.QueryRow(statement, name).Scan(&user.ID, &user.Name, &user.CreatedAt, &user.Favorites, &user.Ratings)
+	if err == sql.ErrNoRows {
+		tools.Logger.Info(fmt.Sprintf("Attempt to get a non-existent user: '%s'\n", name))
+		return user, errors.New("user does not exist")
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return user, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Got user '%s' successfully\n", name))
+	return user, nil
+}
+
+// Добавляет песню в избранное пользователя или убирает из него. Повторный вызов ничего не меняет
+func (r *PostgresRepository) SetFavorite(user, song, group string, favorite bool) error {
+	id, err := userID(r.db, user)
+	if err != nil {
+		return err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to change favorites of a non-existent user: '%s'\n", user))
+		return errors.New("user does not exist")
+	}
+
+	songID, err := r.Exists(song, group)
+	if err != nil {
+		return err
+	}
+	if songID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to favorite a non-existent song: '%s' by '%s'\n", song, group))
+		return errors.New("song does not exist")
+	}
+
+	statement := `INSERT INTO "Favorite" (user_id, song_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	if !favorite {
+		statement = `DELETE FROM "Favorite" WHERE user_id = $1 AND song_id = $2`
+	}
+	_, err = r.db.Exec(statement, id, songID)
+	if err != nil {
+		tools.Logger.Error("Failed to execute query: ", err)
+		return err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Favorite '%s' by '%s' of user '%s' set to %t successfully\n", song, group, user, favorite))
+	return nil
+}
+
+// Ставит песне оценку пользователя, 0 - убирает оценку.
+// Агрегаты оценок песни обновляет триггер update_song_rating
+func (r *PostgresRepository) RateSong(user, song, group string, rating int) (float64, int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		tools.Logger.Error("Failed to begin transaction: ", err)
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	id, err := userID(tx, user)
+	if err != nil {
+		return 0, 0, err
+	}
+	if id == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to rate by a non-existent user: '%s'\n", user))
+		return 0, 0, errors.New("user does not exist")
+	}
+
+	songID, err := lockSong(tx, song, group)
+	if err != nil {
+		return 0, 0, err
+	}
+	if songID == -1 {
+		tools.Logger.Info(fmt.Sprintf("Attempt to rate a non-existent song: '%s' by '%s'\n", song, group))
+		return 0, 0, errors.New("song does not exist")
+	}
+
+	if rating == 0 {
+		_, err = tx.Exec(`DELETE FROM "Rating" WHERE user_id = $1 AND song_id = $2`, id, songID)
+	} else {
+		statement := `
+			INSERT INTO "Rating" (user_id, song_id, rating)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, song_id) DO UPDATE SET rating = EXCLUDED.rating, rated_at = now()`
+		_, err = tx.Exec(statement, id, songID, rating)
+	}
+	if err != nil {
+		tools.Logger.Error("Failed to execute query: ", err)
+		return 0, 0, err
+	}
+
+	var average float64
+	var count int
+	err = tx.QueryRow(`SELECT rating_avg, rating_count FROM "Song" WHERE song_id = $1`, songID).Scan(&average, &count)
+	if err != nil {
+		tools.Logger.Error("Failed to execute SELECT query: ", err)
+		return 0, 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tools.Logger.Error("Failed to commit transaction: ", err)
+		return 0, 0, err
+	}
+
+	tools.Logger.Info(fmt.Sprintf("Song '%s' by '%s' rated %d by '%s' successfully\n", song, group, rating, user))
+	return average, count, nil
+}
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var publicIDFormat = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// Проверяет публичный id песни и приводит его к виду, в котором он хранится: UUID в нижнем регистре
+func ParsePublicID(id string) (string, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if !publicIDFormat.MatchString(id) {
+		return id, errors.New("invalid song id")
+	}
+	return id, nil
+}
+
+// Случайный UUID версии 4, как у gen_random_uuid в PostgreSQL
+func newPublicID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
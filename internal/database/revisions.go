@@ -0,0 +1,20 @@
+package database
+
+import "time"
+
+// Операции, после которых сохраняется ревизия песни
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+	RevisionRevert  = "revert"
+)
+
+// Состояние песни после одной из операций
+type SongRevision struct {
+	SongData
+	Revision  int
+	Operation string
+	CreatedAt time.Time
+}
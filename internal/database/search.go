@@ -0,0 +1,61 @@
+package database
+
+import (
+	"html"
+	"strings"
+	"time"
+)
+
+// Параметры полнотекстового поиска по текстам песен
+type SearchQuery struct {
+	Text     string
+	Language string
+	Limit    int
+}
+
+// Найденная песня с релевантностью и фрагментом текста
+type SearchResult struct {
+	PublicID    string
+	Song        string
+	Group       string
+	ReleaseDate time.Time
+	Rank        float64
+	Snippet     string
+}
+
+// Поддерживаемые конфигурации языка и соответствующие им поисковые колонки
+var searchColumns = map[string]string{
+	"simple":  "text_search_simple",
+	"english": "text_search_english",
+	"russian": "text_search_russian",
+}
+
+// Конфигурация языка по умолчанию
+const DefaultSearchLanguage = "simple"
+
+// Проверяет, поддерживается ли конфигурация языка
+func IsSearchLanguage(language string) bool {
+	_, ok := searchColumns[language]
+	return ok
+}
+
+// Маркеры подсветки найденных слов во фрагменте. Остальной текст фрагмента экранируется,
+// поэтому HTML из текста песни не попадает к клиенту как разметка
+const (
+	highlightStart = "<b>"
+	highlightStop  = "</b>"
+)
+
+// Символы из области для частного использования, которыми PostgreSQL отмечает найденные слова.
+// Из текста песни они удаляются до построения фрагмента
+const (
+	matchStart = "\uE000"
+	matchStop  = "\uE001"
+)
+
+var matchMarkers = strings.NewReplacer(matchStart, highlightStart, matchStop, highlightStop)
+
+// Экранирует HTML во фрагменте от PostgreSQL и заменяет служебные символы на маркеры подсветки
+func escapeSnippet(snippet string) string {
+	return matchMarkers.Replace(html.EscapeString(snippet))
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Песня, похожая на искомую
+type SongCandidate struct {
+	Song       string  `json:"song"`
+	Group      string  `json:"group"`
+	Similarity float64 `json:"similarity"`
+}
+
+// Минимальная похожесть кандидата, совпадает с pg_trgm.similarity_threshold по умолчанию
+const similarityThreshold = 0.3
+
+// Вычисляет похожесть строк по триграммам так же, как similarity() из pg_trgm
+func trigramSimilarity(a, b string) float64 {
+	left := trigrams(a)
+	right := trigrams(b)
+	if len(left) == 0 || len(right) == 0 {
+		return 0
+	}
+
+	common := 0
+	for trigram := range left {
+		if right[trigram] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(left)+len(right)-common)
+}
+
+// Разбивает строку на триграммы: каждое слово дополняется двумя пробелами в начале и одним в конце
+func trigrams(text string) map[string]bool {
+	result := map[string]bool{}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			result[string(padded[i:i+3])] = true
+		}
+	}
+	return result
+}
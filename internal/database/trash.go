@@ -0,0 +1,9 @@
+package database
+
+import "time"
+
+// Песня в корзине
+type TrashedSong struct {
+	SongData
+	DeletedAt time.Time
+}
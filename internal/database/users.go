@@ -0,0 +1,27 @@
+package database
+
+import (
+	"math"
+	"time"
+)
+
+// Пользователь API. Пользователь определяется именем из заголовка X-Actor
+type User struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+	// Число избранных и оцененных песен, песни из корзины не учитываются
+	Favorites int
+	Ratings   int
+}
+
+// Наибольшая оценка песни, наименьшая - 1
+const MaxRating = 5
+
+// Средняя оценка с округлением до сотых, как в колонке rating_avg
+func ratingAverage(sum, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return math.Round(float64(sum)*100/float64(count)) / 100
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"music/internal/services"
+	"net/http"
+	"strings"
+)
+
+// Обработчик /albums
+func AlbumsHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "GET" {
+		albums, unexpectedParams, err := services.GetAlbums(request.URL.Query())
+		if err != nil {
+			albumError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(200)
+		json.NewEncoder(writer).Encode(albums)
+		return
+
+	} else if request.Method == "POST" {
+		unexpectedParams, err := services.AddAlbum(request.URL.Query())
+		if err != nil {
+			albumError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("New album added"))
+		return
+
+	} else if request.Method == "PATCH" {
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			http.Error(writer, "Can't read request body", http.StatusBadRequest)
+			return
+		}
+		defer request.Body.Close()
+
+		var albumUpdate map[string]string
+		err = json.Unmarshal(body, &albumUpdate)
+		if err != nil {
+			http.Error(writer, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		unexpectedParams, err := services.UpdateAlbum(albumUpdate)
+		if err != nil {
+			albumError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Album data updated"))
+		return
+
+	} else if request.Method == "DELETE" {
+		unexpectedParams, err := services.DeleteAlbum(request.URL.Query())
+		if err != nil {
+			albumError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Album deleted"))
+		return
+	}
+
+	http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+}
+
+// Обработчик /albums/tracks
+func AlbumTracksHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "POST" {
+		unexpectedParams, err := services.AddTrack(request.URL.Query())
+		if err != nil {
+			albumError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Song added to album"))
+		return
+
+	} else if request.Method == "DELETE" {
+		unexpectedParams, err := services.RemoveTrack(request.URL.Query())
+		if err != nil {
+			albumError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Song removed from album"))
+		return
+	}
+
+	http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+}
+
+// Отвечает ошибкой для обработчиков альбомов
+func albumError(writer http.ResponseWriter, err error, unexpectedParams []string) {
+	if err.Error() == "unexpected params" {
+		errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
+		http.Error(writer, errorMessage, http.StatusBadRequest)
+	} else if err.Error() == "song does not exist" {
+		songNotFound(writer, err)
+	} else if err.Error() == "album does not exist" {
+		http.Error(writer, "Album does not exist", http.StatusNotFound)
+	} else if err.Error() == "song is not on album" {
+		http.Error(writer, "Song is not on the album", http.StatusNotFound)
+	} else if err.Error() == "album already exists" {
+		http.Error(writer, "Album already exists", http.StatusConflict)
+	} else if err.Error() == "song is already on album" {
+		http.Error(writer, "Song is already on the album", http.StatusConflict)
+	} else if err.Error() == "track is taken" {
+		http.Error(writer, "Another song already has this disc and track number", http.StatusConflict)
+	} else if err.Error() == "incorrect date format" {
+		http.Error(writer, "'releasedate' requires a date in format DD.MM.YYYY", http.StatusBadRequest)
+	} else if err.Error() == "incorrect album type" {
+		http.Error(writer, "'type' must be one of: LP, EP, single, compilation", http.StatusBadRequest)
+	} else if err.Error() == "failed to get albums" {
+		http.Error(writer, "Failed to get albums", http.StatusInternalServerError)
+	} else if err.Error() == "failed to add album" {
+		http.Error(writer, "Failed to add album", http.StatusInternalServerError)
+	} else if err.Error() == "failed to update album" {
+		http.Error(writer, "Failed to update album", http.StatusInternalServerError)
+	} else if err.Error() == "failed to delete album" {
+		http.Error(writer, "Failed to delete album", http.StatusInternalServerError)
+	} else if err.Error() == "failed to add track" {
+		http.Error(writer, "Failed to add song to album", http.StatusInternalServerError)
+	} else if err.Error() == "failed to remove track" {
+		http.Error(writer, "Failed to remove song from album", http.StatusInternalServerError)
+	} else {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// Обертки над AlbumsHandler и AlbumTracksHandler сделаны для генерации Swagger
+
+// @Summary      Get albums
+// @Description  Get albums with their songs ordered by disc and track number.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        album  query    string  false  "Album title"
+// @Param        group  query    string  false  "Group name"
+// @Success      200    {array}  services.AlbumData  "Albums"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /albums [get]
+func getAlbumsHandler(w http.ResponseWriter, r *http.Request) {
+	AlbumsHandler(w, r)
+}
+
+// @Summary      Add an album
+// @Description  Add an album of a group.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        album        query    string  true   "Album title"
+// @Param        group        query    string  true   "Group name"
+// @Param        releasedate  query    string  false  "Release date (DD.MM.YYYY)"
+// @Param        type         query    string  false  "Album type: LP (default), EP, single or compilation"
+// @Success      200    {string} string  "Album added"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      409    {string} string  "Album already exists"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /albums [post]
+func addAlbumHandler(w http.ResponseWriter, r *http.Request) {
+	AlbumsHandler(w, r)
+}
+
+// @Summary      Update an album
+// @Description  Update the release date or type of an album. Pass album and group with the fields to change.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        album  body     object  true  "Album data to update: album, group, releasedate, type"
+// @Success      200    {string} string  "Album updated"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Album not found"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /albums [patch]
+func updateAlbumHandler(w http.ResponseWriter, r *http.Request) {
+	AlbumsHandler(w, r)
+}
+
+// @Summary      Delete an album
+// @Description  Delete an album. Its songs are kept.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        album  query    string  true   "Album title"
+// @Param        group  query    string  true   "Group name"
+// @Success      200    {string} string  "Album deleted"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Album not found"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /albums [delete]
+func deleteAlbumHandler(w http.ResponseWriter, r *http.Request) {
+	AlbumsHandler(w, r)
+}
+
+// @Summary      Add a song to an album
+// @Description  Put a song of the album's group on the album at the given disc and track number.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        album  query    string  true   "Album title"
+// @Param        group  query    string  true   "Group name"
+// @Param        song   query    string  true   "Song name"
+// @Param        track  query    int     true   "Track number"
+// @Param        disc   query    int     false  "Disc number, 1 by default"
+// @Success      200    {string} string  "Song added to album"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Album or song not found"
+// @Failure      409    {string} string  "Song is already on the album or the track number is taken"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /albums/tracks [post]
+func addTrackHandler(w http.ResponseWriter, r *http.Request) {
+	AlbumTracksHandler(w, r)
+}
+
+// @Summary      Remove a song from an album
+// @Description  Remove a song from an album. The song itself is kept.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        album  query    string  true   "Album title"
+// @Param        group  query    string  true   "Group name"
+// @Param        song   query    string  true   "Song name"
+// @Success      200    {string} string  "Song removed from album"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Album or song not found"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /albums/tracks [delete]
+func removeTrackHandler(w http.ResponseWriter, r *http.Request) {
+	AlbumTracksHandler(w, r)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"music/internal/database"
+	"music/internal/services"
+	"net/http"
+	"strings"
+)
+
+// Заголовки с автором изменения и id запроса
+const (
+	actorHeader     = "X-Actor"
+	requestIDHeader = "X-Request-ID"
+)
+
+// Автор изменений, если заголовок X-Actor не передан
+const anonymousActor = "anonymous"
+
+// Наибольшая длина значений X-Actor и X-Request-ID, как у колонок actor и request_id
+const maxHeaderValue = 255
+
+// Значение заголовка без пробелов по краям, обрезанное до maxHeaderValue символов
+func headerValue(request *http.Request, name string) string {
+	value := []rune(strings.TrimSpace(request.Header.Get(name)))
+	if len(value) > maxHeaderValue {
+		value = value[:maxHeaderValue]
+	}
+	return strings.TrimSpace(string(value))
+}
+
+// Определяет автора изменения и id запроса. Если клиент не передал id запроса,
+// он генерируется; id возвращается клиенту в заголовке ответа
+func requestActor(writer http.ResponseWriter, request *http.Request) database.Actor {
+	actor := database.Actor{
+		Name:      headerValue(request, actorHeader),
+		RequestID: headerValue(request, requestIDHeader),
+	}
+	if actor.Name == "" {
+		actor.Name = anonymousActor
+	}
+	if actor.RequestID == "" {
+		id := make([]byte, 16)
+		rand.Read(id)
+		actor.RequestID = hex.EncodeToString(id)
+	}
+
+	writer.Header().Set(requestIDHeader, actor.RequestID)
+	return actor
+}
+
+// @Summary      Get audit log
+// @Description  Get changes made by POST, PATCH and DELETE requests on songs, newest first. The actor is taken from the X-Actor header and the request ID from X-Request-ID.
+// @Tags         audit
+// @Accept       json
+// @Produce      json
+// @Param        song   query    string  false  "Song name"
+// @Param        group  query    string  false  "Group name"
+// @Param        actor  query    string  false  "Actor"
+// @Param        from   query    string  false  "Start of the period, RFC 3339 time or DD.MM.YYYY date"
+// @Param        to     query    string  false  "End of the period, RFC 3339 time or DD.MM.YYYY date (inclusive)"
+// @Param        limit  query    int     false  "Maximum number of entries, 20 by default"
+// @Success      200    {array}  services.AuditEntry  "Audit entries"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /audit [get]
+func AuditHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "GET" {
+		http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entries, unexpectedParams, err := services.GetAudit(request.URL.Query())
+	if err != nil {
+		if err.Error() == "unexpected params" {
+			errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
+			http.Error(writer, errorMessage, http.StatusBadRequest)
+			return
+		} else if err.Error() == "failed to get audit log" {
+			http.Error(writer, "Failed to get audit log", http.StatusInternalServerError)
+			return
+		} else {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(200)
+	json.NewEncoder(writer).Encode(entries)
+}
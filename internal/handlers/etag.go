@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ETag песни - ее версия в кавычках
+func songETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// Разбирает заголовок If-Match: один ETag или список через запятую, слабые ETag (W/"1")
+// тоже принимаются. Возвращает допустимые версии песни, пусто - без проверки (в том числе
+// для "*": песня должна только существовать). false - заголовок не может совпасть ни с одной версией
+func parseIfMatch(header string) ([]int, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	versions := []int{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		value, err := strconv.Unquote(tag)
+		if err != nil || !strings.HasPrefix(tag, `"`) {
+			continue
+		}
+		version, err := strconv.Atoi(value)
+		if err != nil || version < 1 {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	if len(versions) == 0 {
+		return nil, false
+	}
+	return versions, true
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	_ "music/api"
 	"music/internal/services"
@@ -11,6 +13,7 @@ import (
 )
 
 type SongData struct {
+	ID          string    `json:"id"`
 	Song        string    `json:"song"`
 	Group       string    `json:"group"`
 	ReleaseDate time.Time `json:"releaseDate"`
@@ -18,10 +21,20 @@ type SongData struct {
 	Link        string    `json:"link"`
 }
 
+type SongUpdate struct {
+	Song        string `json:"song"`
+	Group       string `json:"group"`
+	NewSong     string `json:"newSong"`
+	NewGroup    string `json:"newGroup"`
+	ReleaseDate string `json:"releasedate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
 func SongsHandler(writer http.ResponseWriter, request *http.Request) {
 	if request.Method == "GET" {
 		query := request.URL.Query()
-		songs, unexpectedParams, err := services.GetSongs(query)
+		songs, unexpectedParams, err := services.GetSongs(query, requestUser(request))
 
 		if err != nil {
 			if err.Error() == "unexpected params" {
@@ -43,20 +56,60 @@ func SongsHandler(writer http.ResponseWriter, request *http.Request) {
 			} else if err.Error() == "incorrect date format" {
 				http.Error(writer, "Invalid date format: "+query["releasedate"][0], http.StatusBadRequest)
 				return
+			} else if err.Error() == "limit is not a number" {
+				http.Error(writer, `"limit" requires a number from 1 to 100`, http.StatusBadRequest)
+				return
+			} else if err.Error() == "invalid sort" {
+				errorMessage := "Invalid sort: " + strings.Join(query["sort"], ",") + ". Allowed fields: song, group, releasedate, rating"
+				http.Error(writer, errorMessage, http.StatusBadRequest)
+				return
+			} else if err.Error() == "no user" {
+				http.Error(writer, "Pass your user name in the X-Actor header to get favorites", http.StatusUnauthorized)
+				return
+			} else if err.Error() == "invalid cursor" {
+				http.Error(writer, "Invalid cursor", http.StatusBadRequest)
+				return
+			} else if err.Error() == "'limit' requires only 1 value" ||
+				err.Error() == "'cursor' requires only 1 value" ||
+				err.Error() == "'cursor' and 'limit' can not be combined with 'page' and 'onpage'" ||
+				err.Error() == "'releasedfrom' requires only 1 value" ||
+				err.Error() == "'releasedto' requires only 1 value" ||
+				err.Error() == "'releasedfrom' requires a date in format DD.MM.YYYY" ||
+				err.Error() == "'releasedto' requires a date in format DD.MM.YYYY" ||
+				err.Error() == "'releasedfrom' must not be after 'releasedto'" ||
+				err.Error() == "'year' requires a year, for example 1994" ||
+				err.Error() == "'decade' requires a decade, for example 1990 or 1990s" ||
+				err.Error() == "'provider' requires youtube, spotify, bandcamp, soundcloud, applemusic, deezer or other" ||
+				err.Error() == "'favorites' requires only 1 value" ||
+				err.Error() == "'favorites' requires true or false" {
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
 			} else {
 				http.Error(writer, "Failed to get music list ", http.StatusInternalServerError)
 				return
 			}
 		}
 
-		writer.WriteHeader(200)
 		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
-		json.NewEncoder(writer).Encode(services.DateToString(songs))
+		// ETag отдается, когда запрос выбрал ровно одну песню
+		if len(songs.Songs) == 1 {
+			writer.Header().Set("ETag", songETag(songs.Songs[0].Version))
+		}
+		writer.WriteHeader(200)
+		if songs.Keyset {
+			json.NewEncoder(writer).Encode(services.SongsPage{
+				Songs: services.DateToString(songs.Songs),
+				Next:  songs.Next,
+				Prev:  songs.Prev,
+			})
+			return
+		}
+		json.NewEncoder(writer).Encode(services.DateToString(songs.Songs))
 		return
 
 	} else if request.Method == "DELETE" {
 		query := request.URL.Query()
-		unexpectedParams, err := services.DeleteSong(query)
+		unexpectedParams, err := services.DeleteSong(query, requestActor(writer, request))
 		if err != nil {
 			if err.Error() == "unexpected params" {
 				errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
@@ -66,7 +119,7 @@ func SongsHandler(writer http.ResponseWriter, request *http.Request) {
 				http.Error(writer, "Failed to delete song", http.StatusInternalServerError)
 				return
 			} else if err.Error() == "song does not exist" {
-				http.Error(writer, "Song does not exist", http.StatusNotFound)
+				songNotFound(writer, err)
 				return
 			} else {
 				http.Error(writer, err.Error(), http.StatusBadRequest)
@@ -93,30 +146,43 @@ func SongsHandler(writer http.ResponseWriter, request *http.Request) {
 			return
 		}
 
-		unexpectedParams, err := services.UpdateSong(songUpdate)
+		versions, ok := parseIfMatch(request.Header.Get("If-Match"))
+		if !ok {
+			http.Error(writer, "Song was modified, fetch it again to get the current ETag", http.StatusPreconditionFailed)
+			return
+		}
+
+		version, unexpectedParams, err := services.UpdateSong(songUpdate, versions, requestActor(writer, request))
 		if err != nil {
 			if err.Error() == "unexpected params" {
 				errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
 				http.Error(writer, errorMessage, http.StatusBadRequest)
 				return
+			} else if err.Error() == "song was modified" {
+				http.Error(writer, "Song was modified, fetch it again to get the current ETag", http.StatusPreconditionFailed)
+				return
+			} else if err.Error() == "song already exists" {
+				http.Error(writer, "Song already exists", http.StatusConflict)
+				return
 			} else if err.Error() == "failed to update song" {
 				http.Error(writer, "Failed to update song data", http.StatusInternalServerError)
 				return
 			} else if err.Error() == "song does not exist" {
-				http.Error(writer, "Song does not exist", http.StatusNotFound)
+				songNotFound(writer, err)
 				return
 			} else {
 				http.Error(writer, err.Error(), http.StatusBadRequest)
 				return
 			}
 		}
+		writer.Header().Set("ETag", songETag(version))
 		writer.WriteHeader(200)
 		writer.Write([]byte("Song data updated"))
 		return
 
 	} else if request.Method == "POST" {
 		query := request.URL.Query()
-		unexpectedParams, err := services.AddSong(query)
+		unexpectedParams, err := services.AddSong(query, requestActor(writer, request))
 		if err != nil {
 			if err.Error() == "unexpected params" {
 				errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
@@ -158,7 +224,7 @@ func TextHandler(writer http.ResponseWriter, request *http.Request) {
 				http.Error(writer, "Failed to get songs text", http.StatusInternalServerError)
 				return
 			} else if err.Error() == "song does not exist" {
-				http.Error(writer, "Song does not exist", http.StatusNotFound)
+				songNotFound(writer, err)
 				return
 			} else {
 				http.Error(writer, err.Error(), http.StatusBadRequest)
@@ -176,6 +242,21 @@ func TextHandler(writer http.ResponseWriter, request *http.Request) {
 	}
 }
 
+// Отвечает 404 со списком похожих песен, если они нашлись
+func songNotFound(writer http.ResponseWriter, err error) {
+	message := "Song does not exist"
+
+	var notFound *services.NotFoundError
+	if errors.As(err, &notFound) && len(notFound.Candidates) != 0 {
+		message += "\nDid you mean:"
+		for _, candidate := range notFound.Candidates {
+			message += fmt.Sprintf("\n'%s' by '%s'", candidate.Song, candidate.Group)
+		}
+	}
+
+	http.Error(writer, message, http.StatusNotFound)
+}
+
 // Обертки над SongHandler сделаны для генерации Swagger
 
 // @Summary      Get a list of songs
@@ -186,12 +267,29 @@ func TextHandler(writer http.ResponseWriter, request *http.Request) {
 // @Param        song        query    string  false  "Song name"
 // @Param        group       query    string  false  "Group name"
 // @Param        releasedate query   string  false  "Release date"
+// @Param        releasedfrom query  string  false  "Released on or after the date (DD.MM.YYYY)"
+// @Param        releasedto  query    string  false  "Released on or before the date (DD.MM.YYYY)"
+// @Param        year        query    string  false  "Comma-separated release years"
+// @Param        decade      query    string  false  "Comma-separated release decades, e.g. 1990 or 1990s"
 // @Param        text        query    string  false  "Song lyrics"
 // @Param        link        query    string  false  "Video link"
+// @Param        album       query    string  false  "Title of any album the song is on"
+// @Param        artist      query    string  false  "Person who was a member of the group when the song was released"
+// @Param        genre       query    string  false  "Genres, comma-separated: any of them by default, all of them with genre[all]"
+// @Param        tag         query    string  false  "Tags, comma-separated: any of them by default, all of them with tag[all]"
+// @Param        provider    query    string  false  "Link providers, comma-separated: songs with a link to any of them (youtube, spotify, bandcamp, soundcloud, applemusic, deezer, other)"
+// @Param        favorites   query    bool    false  "Only favorites of the user from the X-Actor header"
+// @Param        X-Actor     header   string  false  "User name, required with favorites, the header is not authenticated"
 // @Param        page        query    int     false  "Page number"
 // @Param        onpage      query    int     false  "Items per page"
-// @Success      200       {array}  SongData    "List of songs"
+// @Param        song[contains] query string false "Operator filters: song, group, text and link accept [eq], [ieq], [contains], [icontains], [prefix] and [iprefix], song and group also accept [similar] for fuzzy matching"
+// @Param        sort        query    string  false  "Comma-separated sort fields (song, group, releasedate, rating), '-' prefix for descending. Unrated songs have rating 0"
+// @Param        limit       query    int     false  "Items per page for cursor pagination, responds with a songs page"
+// @Param        cursor      query    string  false  "Cursor from 'next' or 'prev' of the previous page"
+// @Success      200       {array}  SongData    "List of songs, or services.SongsPage when 'limit' or 'cursor' is passed"
+// @Header       200        {string} ETag    "Song version, when exactly one song is returned"
 // @Failure      400        {string} string  "Bad request"
+// @Failure      401        {string} string  "Favorites requested without a user"
 // @Failure      500        {string} string  "Internal server error"
 // @Router       /songs [get]
 func getSongHandler(w http.ResponseWriter, r *http.Request) {
@@ -205,6 +303,8 @@ func getSongHandler(w http.ResponseWriter, r *http.Request) {
 // @Produce      json
 // @Param        song        query    string  false  "Song name"
 // @Param        group       query    string  false  "Group name"
+// @Param        X-Actor       header   string  false  "Author of the change, 'anonymous' by default"
+// @Param        X-Request-ID  header   string  false  "Request ID for the audit log, generated when omitted"
 // @Success      200   {string} string  "Song added successfully"
 // @Failure      400   {string} string  "Bad request"
 // @Failure      500   {string} string  "Internal server error"
@@ -214,14 +314,20 @@ func AddSongHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary      Update song data
-// @Description  Update song information in the database.
+// @Description  Update song information in the database. Pass the ETag from GET /songs in If-Match to make sure the song was not changed by someone else.
+// @Description  newSong and newGroup rename the song and move it to another group, which is created if needed. A group left without songs, albums and members is deleted.
 // @Tags         songs
 // @Accept       json
 // @Produce      json
-// @Param        song       body    SongData    true  "Song data to update"
+// @Param        song       body    SongUpdate  true  "Song data to update"
+// @Param        If-Match   header  string      false "ETag of the song version being updated, a comma-separated list or *"
+// @Param        X-Actor       header   string  false  "Author of the change, 'anonymous' by default"
+// @Param        X-Request-ID  header   string  false  "Request ID for the audit log, generated when omitted"
 // @Success      200       {string} string  "Song updated"
 // @Failure      400       {string} string  "Bad request"
 // @Failure      404       {string} string  "Song not found"
+// @Failure      409       {string} string  "Song already exists"
+// @Failure      412       {string} string  "Song was modified"
 // @Failure      500       {string} string  "Internal server error"
 // @Router       /songs [patch]
 func UpdateSongHandler(w http.ResponseWriter, r *http.Request) {
@@ -229,12 +335,14 @@ func UpdateSongHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary      Delete a song
-// @Description  Delete a song from the database.
+// @Description  Move a song to the trash. It can be restored until it is purged. The song is removed from all playlists and does not come back to them on restore.
 // @Tags         songs
 // @Accept       json
 // @Produce      json
 // @Param        song   query    string  true   "Song name"
 // @Param        group  query    string  true   "Group name"
+// @Param        X-Actor       header   string  false  "Author of the change, 'anonymous' by default"
+// @Param        X-Request-ID  header   string  false  "Request ID for the audit log, generated when omitted"
 // @Success      200    {string} string  "Song deleted"
 // @Failure      400    {string} string  "Bad request"
 // @Failure      404    {string} string  "Song not found"
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"music/internal/database"
+	"music/internal/services"
+	"net/http"
+	"strings"
+)
+
+// Обработчик /songs/tags
+func SongLabelsHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "GET" {
+		labels, unexpectedParams, err := services.GetSongLabels(request.URL.Query())
+		if err != nil {
+			labelError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(200)
+		json.NewEncoder(writer).Encode(labels)
+		return
+
+	} else if request.Method == "POST" {
+		unexpectedParams, err := services.AttachLabels(request.URL.Query())
+		if err != nil {
+			labelError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Labels added"))
+		return
+
+	} else if request.Method == "DELETE" {
+		unexpectedParams, err := services.DetachLabels(request.URL.Query())
+		if err != nil {
+			labelError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Labels removed"))
+		return
+	}
+
+	http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+}
+
+// @Summary      Label songs by filter
+// @Description  Add genres and tags to every song matching the filter. The filter takes the same parameters as GET /songs except pagination and sorting, at least one condition is required.
+// @Tags         labels
+// @Accept       json
+// @Produce      json
+// @Param        labels  body     services.LabelsData  true   "Genres and tags to add"
+// @Param        group   query    string  false  "Group name"
+// @Param        genre   query    string  false  "Genre filter"
+// @Param        tag     query    string  false  "Tag filter"
+// @Success      200     {string} string  "Number of songs labeled"
+// @Failure      400     {string} string  "Bad request"
+// @Failure      500     {string} string  "Internal server error"
+// @Router       /songs/tags/bulk [post]
+func BulkLabelsHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "POST" {
+		http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, "Can't read request body", http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	var labels services.LabelsData
+	err = json.Unmarshal(body, &labels)
+	if err != nil {
+		http.Error(writer, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	matched, unexpectedParams, err := services.BulkLabel(request.URL.Query(), requestUser(request), labels)
+	if err != nil {
+		labelError(writer, err, unexpectedParams)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write([]byte(fmt.Sprintf("Labeled %d songs", matched)))
+}
+
+// @Summary      Get genres
+// @Description  Get genres with the number of songs in each, most popular first.
+// @Tags         labels
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}  services.LabelData  "Genres"
+// @Failure      400  {string} string  "Bad request"
+// @Failure      500  {string} string  "Internal server error"
+// @Router       /genres [get]
+func GenresHandler(writer http.ResponseWriter, request *http.Request) {
+	labelsHandler(writer, request, database.LabelGenre)
+}
+
+// @Summary      Get tags
+// @Description  Get tags with the number of songs in each, most popular first.
+// @Tags         labels
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}  services.LabelData  "Tags"
+// @Failure      400  {string} string  "Bad request"
+// @Failure      500  {string} string  "Internal server error"
+// @Router       /tags [get]
+func TagsHandler(writer http.ResponseWriter, request *http.Request) {
+	labelsHandler(writer, request, database.LabelTag)
+}
+
+// Отдает список меток вида kind
+func labelsHandler(writer http.ResponseWriter, request *http.Request, kind database.LabelKind) {
+	if request.Method != "GET" {
+		http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	labels, unexpectedParams, err := services.GetLabels(request.URL.Query(), kind)
+	if err != nil {
+		labelError(writer, err, unexpectedParams)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(200)
+	json.NewEncoder(writer).Encode(labels)
+}
+
+// Отвечает ошибкой для обработчиков жанров и тегов
+func labelError(writer http.ResponseWriter, err error, unexpectedParams []string) {
+	if err.Error() == "unexpected params" {
+		errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
+		http.Error(writer, errorMessage, http.StatusBadRequest)
+	} else if err.Error() == "song does not exist" {
+		songNotFound(writer, err)
+	} else if err.Error() == "no labels" {
+		http.Error(writer, "Pass at least one genre or tag", http.StatusBadRequest)
+	} else if err.Error() == "empty label" {
+		http.Error(writer, "Genre and tag names must not be empty", http.StatusBadRequest)
+	} else if err.Error() == "label too long" {
+		http.Error(writer, "Genre and tag names must be at most 64 characters", http.StatusBadRequest)
+	} else if err.Error() == "no filter" {
+		http.Error(writer, "Pass at least one filter condition, labeling the whole catalogue is not allowed", http.StatusBadRequest)
+	} else if err.Error() == "failed to get labels" {
+		http.Error(writer, "Failed to get labels", http.StatusInternalServerError)
+	} else if err.Error() == "failed to update labels" {
+		http.Error(writer, "Failed to update labels", http.StatusInternalServerError)
+	} else {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// Обертки над SongLabelsHandler сделаны для генерации Swagger
+
+// @Summary      Get song labels
+// @Description  Get genres and tags of a song.
+// @Tags         labels
+// @Accept       json
+// @Produce      json
+// @Param        song   query    string  true  "Song name"
+// @Param        group  query    string  true  "Group name"
+// @Success      200    {object} services.LabelsData  "Genres and tags"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Song not found"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /songs/tags [get]
+func getSongLabelsHandler(w http.ResponseWriter, r *http.Request) {
+	SongLabelsHandler(w, r)
+}
+
+// @Summary      Add song labels
+// @Description  Add genres and tags to a song. Names are comma-separated, trimmed and lowercased; missing genres and tags are created.
+// @Tags         labels
+// @Accept       json
+// @Produce      json
+// @Param        song   query    string  true   "Song name"
+// @Param        group  query    string  true   "Group name"
+// @Param        genre  query    string  false  "Genres to add"
+// @Param        tag    query    string  false  "Tags to add"
+// @Success      200    {string} string  "Labels added"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Song not found"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /songs/tags [post]
+func attachLabelsHandler(w http.ResponseWriter, r *http.Request) {
+	SongLabelsHandler(w, r)
+}
+
+// @Summary      Remove song labels
+// @Description  Remove genres and tags from a song. Labels the song does not have are skipped.
+// @Tags         labels
+// @Accept       json
+// @Produce      json
+// @Param        song   query    string  true   "Song name"
+// @Param        group  query    string  true   "Group name"
+// @Param        genre  query    string  false  "Genres to remove"
+// @Param        tag    query    string  false  "Tags to remove"
+// @Success      200    {string} string  "Labels removed"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Song not found"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /songs/tags [delete]
+func detachLabelsHandler(w http.ResponseWriter, r *http.Request) {
+	SongLabelsHandler(w, r)
+}
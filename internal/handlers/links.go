@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"music/internal/services"
+	"net/http"
+	"strings"
+)
+
+// Обработчик /songs/links
+func SongLinksHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "GET" {
+		links, unexpectedParams, err := services.GetSongLinks(request.URL.Query())
+		if err != nil {
+			linkError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(200)
+		json.NewEncoder(writer).Encode(links)
+		return
+
+	} else if request.Method == "POST" {
+		link, unexpectedParams, err := services.AddLink(request.URL.Query())
+		if err != nil {
+			linkError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(200)
+		json.NewEncoder(writer).Encode(link)
+		return
+
+	} else if request.Method == "DELETE" {
+		unexpectedParams, err := services.DeleteLink(request.URL.Query())
+		if err != nil {
+			linkError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Link removed"))
+		return
+	}
+
+	http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+}
+
+// Отвечает ошибкой для обработчика ссылок
+func linkError(writer http.ResponseWriter, err error, unexpectedParams []string) {
+	if err.Error() == "unexpected params" {
+		errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
+		http.Error(writer, errorMessage, http.StatusBadRequest)
+	} else if err.Error() == "song does not exist" {
+		songNotFound(writer, err)
+	} else if err.Error() == "link does not exist" {
+		http.Error(writer, "Song has no such link", http.StatusNotFound)
+	} else if err.Error() == "link already exists" {
+		http.Error(writer, "Song already has this link", http.StatusConflict)
+	} else if err.Error() == "failed to get links" {
+		http.Error(writer, "Failed to get links", http.StatusInternalServerError)
+	} else if err.Error() == "failed to add link" {
+		http.Error(writer, "Failed to add link", http.StatusInternalServerError)
+	} else if err.Error() == "failed to delete link" {
+		http.Error(writer, "Failed to remove link", http.StatusInternalServerError)
+	} else {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// Обертки над SongLinksHandler сделаны для генерации Swagger
+
+// @Summary      Get song links
+// @Description  Get links of a song in the order they were added.
+// @Tags         links
+// @Accept       json
+// @Produce      json
+// @Param        song   query    string  true  "Song name"
+// @Param        group  query    string  true  "Group name"
+// @Success      200    {array}  services.LinkData  "Links"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Song not found"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /songs/links [get]
+func getSongLinksHandler(w http.ResponseWriter, r *http.Request) {
+	SongLinksHandler(w, r)
+}
+
+// @Summary      Add a song link
+// @Description  Add a link to a song. The provider (youtube, spotify, bandcamp, soundcloud, applemusic, deezer or other) is detected from the link, and the link is normalized: youtu.be, /watch, /shorts and /embed links of YouTube become https://www.youtube.com/watch?v=ID, tracking parameters of known providers are dropped.
+// @Tags         links
+// @Accept       json
+// @Produce      json
+// @Param        song   query    string  true   "Song name"
+// @Param        group  query    string  true   "Group name"
+// @Param        url    query    string  true   "Link, http or https"
+// @Param        label  query    string  false  "Label, up to 255 characters"
+// @Success      200    {object} services.LinkData  "Added link with the detected provider and normalized URL"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Song not found"
+// @Failure      409    {string} string  "Song already has this link"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /songs/links [post]
+func addSongLinkHandler(w http.ResponseWriter, r *http.Request) {
+	SongLinksHandler(w, r)
+}
+
+// @Summary      Remove a song link
+// @Description  Remove a link of a song. The link may be passed in any form that normalizes to the stored one.
+// @Tags         links
+// @Accept       json
+// @Produce      json
+// @Param        song   query    string  true  "Song name"
+// @Param        group  query    string  true  "Group name"
+// @Param        url    query    string  true  "Link"
+// @Success      200    {string} string  "Link removed"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Song or link not found"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /songs/links [delete]
+func deleteSongLinkHandler(w http.ResponseWriter, r *http.Request) {
+	SongLinksHandler(w, r)
+}
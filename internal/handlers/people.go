@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"music/internal/services"
+	"net/http"
+	"strings"
+)
+
+// Обработчик /lineup
+func LineupHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "GET" {
+		lineup, unexpectedParams, err := services.GetLineup(request.URL.Query())
+		if err != nil {
+			memberError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(200)
+		json.NewEncoder(writer).Encode(lineup)
+		return
+
+	} else if request.Method == "POST" {
+		unexpectedParams, err := services.AddMember(request.URL.Query())
+		if err != nil {
+			memberError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Member added"))
+		return
+
+	} else if request.Method == "DELETE" {
+		unexpectedParams, err := services.DeleteMember(request.URL.Query())
+		if err != nil {
+			memberError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Member deleted"))
+		return
+	}
+
+	http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+}
+
+// @Summary      Get groups of a person
+// @Description  Get all groups a person has played in, with roles and periods.
+// @Tags         people
+// @Accept       json
+// @Produce      json
+// @Param        person  query    string  true  "Person name"
+// @Success      200     {array}  services.MemberData  "Memberships"
+// @Failure      400     {string} string  "Bad request"
+// @Failure      404     {string} string  "Person not found"
+// @Failure      500     {string} string  "Internal server error"
+// @Router       /people/groups [get]
+func PersonGroupsHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "GET" {
+		http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	groups, unexpectedParams, err := services.GetPersonGroups(request.URL.Query())
+	if err != nil {
+		memberError(writer, err, unexpectedParams)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(200)
+	json.NewEncoder(writer).Encode(groups)
+}
+
+// Отвечает ошибкой для обработчиков состава групп
+func memberError(writer http.ResponseWriter, err error, unexpectedParams []string) {
+	if err.Error() == "unexpected params" {
+		errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
+		http.Error(writer, errorMessage, http.StatusBadRequest)
+	} else if err.Error() == "group does not exist" {
+		http.Error(writer, "Group does not exist", http.StatusNotFound)
+	} else if err.Error() == "person does not exist" {
+		http.Error(writer, "Person does not exist", http.StatusNotFound)
+	} else if err.Error() == "membership does not exist" {
+		http.Error(writer, "Membership does not exist", http.StatusNotFound)
+	} else if err.Error() == "membership already exists" {
+		http.Error(writer, "Membership already exists", http.StatusConflict)
+	} else if err.Error() == "failed to get lineup" {
+		http.Error(writer, "Failed to get lineup", http.StatusInternalServerError)
+	} else if err.Error() == "failed to add member" {
+		http.Error(writer, "Failed to add member", http.StatusInternalServerError)
+	} else if err.Error() == "failed to delete member" {
+		http.Error(writer, "Failed to delete member", http.StatusInternalServerError)
+	} else if err.Error() == "failed to get person groups" {
+		http.Error(writer, "Failed to get person groups", http.StatusInternalServerError)
+	} else {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// Обертки над LineupHandler сделаны для генерации Swagger
+
+// @Summary      Get group lineup
+// @Description  Get members of a group at a given date.
+// @Tags         people
+// @Accept       json
+// @Produce      json
+// @Param        group  query    string  true   "Group name"
+// @Param        date   query    string  false  "Date (DD.MM.YYYY), today by default"
+// @Success      200    {array}  services.MemberData  "Members"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Group not found"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /lineup [get]
+func getLineupHandler(w http.ResponseWriter, r *http.Request) {
+	LineupHandler(w, r)
+}
+
+// @Summary      Add a group member
+// @Description  Record that a person played in a group in a role during a period. The person and the group are created if needed.
+// @Tags         people
+// @Accept       json
+// @Produce      json
+// @Param        group   query    string  true   "Group name"
+// @Param        person  query    string  true   "Person name"
+// @Param        role    query    string  true   "Role, e.g. vocals or guitar"
+// @Param        from    query    string  false  "First day in the group (DD.MM.YYYY)"
+// @Param        to      query    string  false  "Last day in the group (DD.MM.YYYY), empty while still active"
+// @Success      200     {string} string  "Member added"
+// @Failure      400     {string} string  "Bad request"
+// @Failure      409     {string} string  "Membership already exists"
+// @Failure      500     {string} string  "Internal server error"
+// @Router       /lineup [post]
+func addMemberHandler(w http.ResponseWriter, r *http.Request) {
+	LineupHandler(w, r)
+}
+
+// @Summary      Delete a group member
+// @Description  Delete a period of a person in a group. Without 'from' all periods in the role are deleted.
+// @Tags         people
+// @Accept       json
+// @Produce      json
+// @Param        group   query    string  true   "Group name"
+// @Param        person  query    string  true   "Person name"
+// @Param        role    query    string  true   "Role"
+// @Param        from    query    string  false  "First day of the period to delete (DD.MM.YYYY)"
+// @Success      200     {string} string  "Member deleted"
+// @Failure      400     {string} string  "Bad request"
+// @Failure      404     {string} string  "Membership not found"
+// @Failure      500     {string} string  "Internal server error"
+// @Router       /lineup [delete]
+func deleteMemberHandler(w http.ResponseWriter, r *http.Request) {
+	LineupHandler(w, r)
+}
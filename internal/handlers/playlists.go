@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"music/internal/services"
+	"net/http"
+	"strings"
+)
+
+// Обработчик /playlists
+func PlaylistsHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "GET" {
+		query := request.URL.Query()
+
+		// С параметром playlist отдается один плейлист с песнями
+		var result any
+		var unexpectedParams []string
+		var err error
+		if query.Has("playlist") {
+			result, unexpectedParams, err = services.GetPlaylist(query)
+		} else {
+			result, unexpectedParams, err = services.GetPlaylists(query)
+		}
+		if err != nil {
+			playlistError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(200)
+		json.NewEncoder(writer).Encode(result)
+		return
+
+	} else if request.Method == "POST" {
+		unexpectedParams, err := services.AddPlaylist(request.URL.Query())
+		if err != nil {
+			playlistError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("New playlist added"))
+		return
+
+	} else if request.Method == "PATCH" {
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			http.Error(writer, "Can't read request body", http.StatusBadRequest)
+			return
+		}
+		defer request.Body.Close()
+
+		var playlistUpdate map[string]string
+		err = json.Unmarshal(body, &playlistUpdate)
+		if err != nil {
+			http.Error(writer, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		unexpectedParams, err := services.RenamePlaylist(playlistUpdate)
+		if err != nil {
+			playlistError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Playlist renamed"))
+		return
+
+	} else if request.Method == "DELETE" {
+		unexpectedParams, err := services.DeletePlaylist(request.URL.Query())
+		if err != nil {
+			playlistError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Playlist deleted"))
+		return
+	}
+
+	http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+}
+
+// Обработчик /playlists/entries
+func PlaylistEntriesHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "POST" {
+		position, unexpectedParams, err := services.AddEntry(request.URL.Query())
+		if err != nil {
+			playlistError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte(fmt.Sprintf("Song added to playlist at position %d", position)))
+		return
+
+	} else if request.Method == "PATCH" {
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			http.Error(writer, "Can't read request body", http.StatusBadRequest)
+			return
+		}
+		defer request.Body.Close()
+
+		var move services.MoveEntryData
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&move)
+		if err != nil {
+			http.Error(writer, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		err = services.MoveEntry(move)
+		if err != nil {
+			playlistError(writer, err, nil)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Song moved"))
+		return
+
+	} else if request.Method == "DELETE" {
+		unexpectedParams, err := services.RemoveEntry(request.URL.Query())
+		if err != nil {
+			playlistError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Song removed from playlist"))
+		return
+	}
+
+	http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+}
+
+// Отвечает ошибкой для обработчиков плейлистов
+func playlistError(writer http.ResponseWriter, err error, unexpectedParams []string) {
+	if err.Error() == "unexpected params" {
+		errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
+		http.Error(writer, errorMessage, http.StatusBadRequest)
+	} else if err.Error() == "song does not exist" {
+		songNotFound(writer, err)
+	} else if err.Error() == "playlist does not exist" {
+		http.Error(writer, "Playlist does not exist", http.StatusNotFound)
+	} else if err.Error() == "entry does not exist" {
+		http.Error(writer, "There is no song at this position", http.StatusNotFound)
+	} else if err.Error() == "playlist already exists" {
+		http.Error(writer, "Playlist already exists", http.StatusConflict)
+	} else if err.Error() == "position out of range" {
+		http.Error(writer, "Position is past the end of the playlist", http.StatusBadRequest)
+	} else if err.Error() == "empty playlist name" {
+		http.Error(writer, "Playlist name must not be empty", http.StatusBadRequest)
+	} else if err.Error() == "failed to get playlists" {
+		http.Error(writer, "Failed to get playlists", http.StatusInternalServerError)
+	} else if err.Error() == "failed to add playlist" {
+		http.Error(writer, "Failed to add playlist", http.StatusInternalServerError)
+	} else if err.Error() == "failed to rename playlist" {
+		http.Error(writer, "Failed to rename playlist", http.StatusInternalServerError)
+	} else if err.Error() == "failed to delete playlist" {
+		http.Error(writer, "Failed to delete playlist", http.StatusInternalServerError)
+	} else if err.Error() == "failed to add entry" {
+		http.Error(writer, "Failed to add song to playlist", http.StatusInternalServerError)
+	} else if err.Error() == "failed to remove entry" {
+		http.Error(writer, "Failed to remove song from playlist", http.StatusInternalServerError)
+	} else if err.Error() == "failed to move entry" {
+		http.Error(writer, "Failed to move song", http.StatusInternalServerError)
+	} else {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// Обертки над PlaylistsHandler и PlaylistEntriesHandler сделаны для генерации Swagger
+
+// @Summary      Get playlists
+// @Description  Without parameters get all playlists with the number of songs. With playlist get one playlist with its songs in order.
+// @Tags         playlists
+// @Accept       json
+// @Produce      json
+// @Param        playlist  query    string  false  "Playlist name"
+// @Success      200       {array}  services.PlaylistData  "Playlists"
+// @Success      200       {object} services.PlaylistEntriesData  "Playlist with songs, when playlist is passed"
+// @Failure      400       {string} string  "Bad request"
+// @Failure      404       {string} string  "Playlist not found"
+// @Failure      500       {string} string  "Internal server error"
+// @Router       /playlists [get]
+func getPlaylistsHandler(w http.ResponseWriter, r *http.Request) {
+	PlaylistsHandler(w, r)
+}
+
+// @Summary      Create a playlist
+// @Description  Create an empty playlist.
+// @Tags         playlists
+// @Accept       json
+// @Produce      json
+// @Param        playlist  query    string  true  "Playlist name"
+// @Success      200       {string} string  "Playlist added"
+// @Failure      400       {string} string  "Bad request"
+// @Failure      409       {string} string  "Playlist already exists"
+// @Failure      500       {string} string  "Internal server error"
+// @Router       /playlists [post]
+func addPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	PlaylistsHandler(w, r)
+}
+
+// @Summary      Rename a playlist
+// @Description  Rename a playlist. Pass the current name in playlist and the new one in name.
+// @Tags         playlists
+// @Accept       json
+// @Produce      json
+// @Param        playlist  body     object  true  "Playlist to rename: playlist, name"
+// @Success      200       {string} string  "Playlist renamed"
+// @Failure      400       {string} string  "Bad request"
+// @Failure      404       {string} string  "Playlist not found"
+// @Failure      409       {string} string  "Playlist with the new name already exists"
+// @Failure      500       {string} string  "Internal server error"
+// @Router       /playlists [patch]
+func renamePlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	PlaylistsHandler(w, r)
+}
+
+// @Summary      Delete a playlist
+// @Description  Delete a playlist. Its songs are kept.
+// @Tags         playlists
+// @Accept       json
+// @Produce      json
+// @Param        playlist  query    string  true  "Playlist name"
+// @Success      200       {string} string  "Playlist deleted"
+// @Failure      400       {string} string  "Bad request"
+// @Failure      404       {string} string  "Playlist not found"
+// @Failure      500       {string} string  "Internal server error"
+// @Router       /playlists [delete]
+func deletePlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	PlaylistsHandler(w, r)
+}
+
+// @Summary      Add a song to a playlist
+// @Description  Put a song into a playlist at the given position, songs from this position on move down. Without position the song goes to the end. The same song may be added more than once.
+// @Tags         playlists
+// @Accept       json
+// @Produce      json
+// @Param        playlist  query    string  true   "Playlist name"
+// @Param        song      query    string  true   "Song name"
+// @Param        group     query    string  true   "Group name"
+// @Param        position  query    int     false  "Position, from 1"
+// @Success      200       {string} string  "Song added, with its position"
+// @Failure      400       {string} string  "Bad request"
+// @Failure      404       {string} string  "Playlist or song not found"
+// @Failure      500       {string} string  "Internal server error"
+// @Router       /playlists/entries [post]
+func addEntryHandler(w http.ResponseWriter, r *http.Request) {
+	PlaylistEntriesHandler(w, r)
+}
+
+// @Summary      Move a song in a playlist
+// @Description  Move the song at position to position to, songs in between shift by one.
+// @Tags         playlists
+// @Accept       json
+// @Produce      json
+// @Param        move  body     services.MoveEntryData  true  "Playlist, current and new position"
+// @Success      200   {string} string  "Song moved"
+// @Failure      400   {string} string  "Bad request"
+// @Failure      404   {string} string  "Playlist or position not found"
+// @Failure      500   {string} string  "Internal server error"
+// @Router       /playlists/entries [patch]
+func moveEntryHandler(w http.ResponseWriter, r *http.Request) {
+	PlaylistEntriesHandler(w, r)
+}
+
+// @Summary      Remove a song from a playlist
+// @Description  Remove the song at a position, the following songs move up.
+// @Tags         playlists
+// @Accept       json
+// @Produce      json
+// @Param        playlist  query    string  true  "Playlist name"
+// @Param        position  query    int     true  "Position, from 1"
+// @Success      200       {string} string  "Song removed from playlist"
+// @Failure      400       {string} string  "Bad request"
+// @Failure      404       {string} string  "Playlist or position not found"
+// @Failure      500       {string} string  "Internal server error"
+// @Router       /playlists/entries [delete]
+func removeEntryHandler(w http.ResponseWriter, r *http.Request) {
+	PlaylistEntriesHandler(w, r)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"music/internal/services"
+	"net/http"
+	"strings"
+)
+
+// Обработчик /plays
+func PlaysHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "GET" {
+		plays, unexpectedParams, err := services.GetPlays(request.URL.Query(), requestUser(request))
+		if err != nil {
+			playError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(200)
+		json.NewEncoder(writer).Encode(plays)
+		return
+
+	} else if request.Method == "POST" {
+		unexpectedParams, err := services.RecordPlay(request.URL.Query(), requestUser(request))
+		if err != nil {
+			playError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Play recorded"))
+		return
+	}
+
+	http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+}
+
+// Обработчик /charts
+func ChartsHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "GET" {
+		chart, unexpectedParams, err := services.GetChart(request.URL.Query())
+		if err != nil {
+			playError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(200)
+		json.NewEncoder(writer).Encode(chart)
+		return
+	}
+
+	http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+}
+
+// Отвечает ошибкой для обработчиков прослушиваний и чартов
+func playError(writer http.ResponseWriter, err error, unexpectedParams []string) {
+	if err.Error() == "unexpected params" {
+		errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
+		http.Error(writer, errorMessage, http.StatusBadRequest)
+	} else if err.Error() == "song does not exist" {
+		songNotFound(writer, err)
+	} else if err.Error() == "no user" {
+		http.Error(writer, fmt.Sprintf("Pass your user name in the %s header", actorHeader), http.StatusUnauthorized)
+	} else if err.Error() == "unknown user" {
+		http.Error(writer, "User is not registered, add it with POST /users first", http.StatusUnauthorized)
+	} else if err.Error() == "failed to record play" {
+		http.Error(writer, "Failed to record play", http.StatusInternalServerError)
+	} else if err.Error() == "failed to get plays" {
+		http.Error(writer, "Failed to get plays", http.StatusInternalServerError)
+	} else if err.Error() == "failed to get chart" {
+		http.Error(writer, "Failed to get chart", http.StatusInternalServerError)
+	} else {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// Обертки над PlaysHandler и ChartsHandler сделаны для генерации Swagger
+
+// @Summary      Get listening history
+// @Description  Get the latest plays of the user from the X-Actor header, newest first. Plays of songs in the trash are not shown.
+// @Tags         plays
+// @Accept       json
+// @Produce      json
+// @Param        limit    query    int     false  "Number of plays, from 1 to 100, 20 by default"
+// @Param        X-Actor  header   string  true   "Registered user name, the header is not authenticated"
+// @Success      200      {array}  services.PlayData  "Plays"
+// @Failure      400      {string} string  "Bad request"
+// @Failure      401      {string} string  "User is not passed or not registered"
+// @Failure      500      {string} string  "Internal server error"
+// @Router       /plays [get]
+func getPlaysHandler(w http.ResponseWriter, r *http.Request) {
+	PlaysHandler(w, r)
+}
+
+// @Summary      Record a play
+// @Description  Record that the user from the X-Actor header played a song. Plays can not be changed or removed afterwards.
+// @Tags         plays
+// @Accept       json
+// @Produce      json
+// @Param        song      query    string  true   "Song name"
+// @Param        group     query    string  true   "Group name"
+// @Param        duration  query    int     true   "Seconds played"
+// @Param        playedat  query    string  false  "Time of the play in RFC 3339, now by default"
+// @Param        X-Actor   header   string  true   "Registered user name, the header is not authenticated"
+// @Success      200       {string} string  "Play recorded"
+// @Failure      400       {string} string  "Bad request"
+// @Failure      401       {string} string  "User is not passed or not registered"
+// @Failure      404       {string} string  "Song not found"
+// @Failure      500       {string} string  "Internal server error"
+// @Router       /plays [post]
+func recordPlayHandler(w http.ResponseWriter, r *http.Request) {
+	PlaysHandler(w, r)
+}
+
+// @Summary      Get charts
+// @Description  Get top songs and groups by number of plays for a day, a week from Monday or a calendar month containing date, or for the days from from to to. Ranks are compared with the previous period: the previous day, week or month, or as many days right before from. Days are counted in UTC.
+// @Tags         plays
+// @Accept       json
+// @Produce      json
+// @Param        period  query    string  false  "day, week or month, week by default"
+// @Param        date    query    string  false  "Day within the period in format DD.MM.YYYY, today by default"
+// @Param        from    query    string  false  "First day of a custom period in format DD.MM.YYYY"
+// @Param        to      query    string  false  "Last day of a custom period in format DD.MM.YYYY"
+// @Param        limit   query    int     false  "Number of songs and groups, from 1 to 100, 10 by default"
+// @Success      200     {object} services.ChartData  "Chart"
+// @Failure      400     {string} string  "Bad request"
+// @Failure      500     {string} string  "Internal server error"
+// @Router       /charts [get]
+func getChartsHandler(w http.ResponseWriter, r *http.Request) {
+	ChartsHandler(w, r)
+}
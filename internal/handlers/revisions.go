@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"music/internal/services"
+	"net/http"
+	"strings"
+)
+
+// @Summary      Get song revisions
+// @Description  Get the history of a song: its state after every create, update, delete, restore and revert.
+// @Tags         revisions
+// @Accept       json
+// @Produce      json
+// @Param        song   query    string  true   "Song name"
+// @Param        group  query    string  true   "Group name"
+// @Success      200    {array}  services.Revision  "Song revisions"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Song not found"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /songs/revisions [get]
+func RevisionsHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "GET" {
+		http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	revisions, unexpectedParams, err := services.GetRevisions(request.URL.Query())
+	if err != nil {
+		revisionError(writer, err, unexpectedParams)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(200)
+	json.NewEncoder(writer).Encode(revisions)
+}
+
+// @Summary      Diff song lyrics
+// @Description  Line-level diff of song lyrics between two revisions.
+// @Tags         revisions
+// @Accept       json
+// @Produce      json
+// @Param        song   query    string  true   "Song name"
+// @Param        group  query    string  true   "Group name"
+// @Param        from   query    int     true   "Old revision"
+// @Param        to     query    int     true   "New revision"
+// @Success      200    {array}  tools.DiffLine  "Diff lines"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Song or revision not found"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /songs/revisions/diff [get]
+func DiffHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "GET" {
+		http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	diff, unexpectedParams, err := services.DiffRevisions(request.URL.Query())
+	if err != nil {
+		revisionError(writer, err, unexpectedParams)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(200)
+	json.NewEncoder(writer).Encode(diff)
+}
+
+// @Summary      Revert a song
+// @Description  Restore release date, lyrics and link of a song from a revision.
+// @Tags         revisions
+// @Accept       json
+// @Produce      json
+// @Param        song      query    string  true   "Song name"
+// @Param        group     query    string  true   "Group name"
+// @Param        revision  query    int     true   "Revision to revert to"
+// @Param        X-Actor       header   string  false  "Author of the change, 'anonymous' by default"
+// @Param        X-Request-ID  header   string  false  "Request ID for the audit log, generated when omitted"
+// @Success      200       {string} string  "Song reverted"
+// @Failure      400       {string} string  "Bad request"
+// @Failure      404       {string} string  "Song or revision not found"
+// @Failure      500       {string} string  "Internal server error"
+// @Router       /songs/revert [post]
+func RevertHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "POST" {
+		http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	unexpectedParams, err := services.RevertSong(request.URL.Query(), requestActor(writer, request))
+	if err != nil {
+		revisionError(writer, err, unexpectedParams)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write([]byte("Song reverted"))
+}
+
+// Отвечает ошибкой для обработчиков ревизий
+func revisionError(writer http.ResponseWriter, err error, unexpectedParams []string) {
+	if err.Error() == "unexpected params" {
+		errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
+		http.Error(writer, errorMessage, http.StatusBadRequest)
+	} else if err.Error() == "song does not exist" {
+		songNotFound(writer, err)
+	} else if err.Error() == "revision does not exist" {
+		http.Error(writer, "Revision does not exist", http.StatusNotFound)
+	} else if err.Error() == "failed to get revisions" {
+		http.Error(writer, "Failed to get revisions", http.StatusInternalServerError)
+	} else if err.Error() == "failed to revert song" {
+		http.Error(writer, "Failed to revert song", http.StatusInternalServerError)
+	} else {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"music/internal/services"
+	"net/http"
+	"strings"
+)
+
+// @Summary      Search songs by lyrics
+// @Description  Full-text search over song lyrics, ranked by relevance with highlighted snippets. Matches are wrapped in <b></b>, the rest of the snippet is HTML-escaped.
+// @Tags         search
+// @Accept       json
+// @Produce      json
+// @Param        q         query    string  true   "Line or words from the lyrics"
+// @Param        language  query    string  false  "Text search configuration: simple, english or russian"
+// @Param        limit     query    int     false  "Maximum number of results"
+// @Success      200       {array}  services.SearchResult  "Found songs"
+// @Failure      400       {string} string  "Bad request"
+// @Failure      500       {string} string  "Internal server error"
+// @Router       /search [get]
+func SearchHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "GET" {
+		http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := request.URL.Query()
+	results, unexpectedParams, err := services.SearchSongs(query)
+	if err != nil {
+		if err.Error() == "unexpected params" {
+			errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
+			http.Error(writer, errorMessage, http.StatusBadRequest)
+			return
+		} else if err.Error() == "unsupported language" {
+			http.Error(writer, "Unsupported language: "+query.Get("language"), http.StatusBadRequest)
+			return
+		} else if err.Error() == "failed to search songs" {
+			http.Error(writer, "Failed to search songs", http.StatusInternalServerError)
+			return
+		} else {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(200)
+	json.NewEncoder(writer).Encode(results)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"music/internal/services"
+	"net/http"
+	"strings"
+)
+
+// Обработчик /songs/{id}
+func SongByIDHandler(writer http.ResponseWriter, request *http.Request) {
+	id := request.PathValue("id")
+
+	if request.Method == "GET" {
+		song, err := services.GetSongByID(id)
+		if err != nil {
+			songByIDError(writer, err, nil)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.Header().Set("ETag", songETag(song.Version))
+		writer.WriteHeader(200)
+		json.NewEncoder(writer).Encode(song)
+		return
+
+	} else if request.Method == "PATCH" {
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			http.Error(writer, "Can't read request body", http.StatusBadRequest)
+			return
+		}
+		defer request.Body.Close()
+
+		var songUpdate map[string]string
+		err = json.Unmarshal(body, &songUpdate)
+		if err != nil {
+			http.Error(writer, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		versions, ok := parseIfMatch(request.Header.Get("If-Match"))
+		if !ok {
+			http.Error(writer, "Song was modified, fetch it again to get the current ETag", http.StatusPreconditionFailed)
+			return
+		}
+
+		version, unexpectedParams, err := services.UpdateSongByID(id, songUpdate, versions, requestActor(writer, request))
+		if err != nil {
+			songByIDError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.Header().Set("ETag", songETag(version))
+		writer.WriteHeader(200)
+		writer.Write([]byte("Song data updated"))
+		return
+
+	} else if request.Method == "DELETE" {
+		err := services.DeleteSongByID(id, requestActor(writer, request))
+		if err != nil {
+			songByIDError(writer, err, nil)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Song deleted"))
+		return
+	}
+
+	http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+}
+
+// Обработчик /songs/{id}/text
+func SongTextByIDHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "GET" {
+		text, unexpectedParams, err := services.GetTextByID(request.PathValue("id"), request.URL.Query())
+		if err != nil {
+			songByIDError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(200)
+		json.NewEncoder(writer).Encode(text)
+		return
+	}
+
+	http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+}
+
+// Отвечает ошибкой для обработчиков песни по id
+func songByIDError(writer http.ResponseWriter, err error, unexpectedParams []string) {
+	if err.Error() == "unexpected params" {
+		errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
+		http.Error(writer, errorMessage, http.StatusBadRequest)
+	} else if err.Error() == "invalid song id" {
+		http.Error(writer, "Song id must be a UUID", http.StatusBadRequest)
+	} else if err.Error() == "song does not exist" {
+		songNotFound(writer, err)
+	} else if err.Error() == "song was modified" {
+		http.Error(writer, "Song was modified, fetch it again to get the current ETag", http.StatusPreconditionFailed)
+	} else if err.Error() == "song already exists" {
+		http.Error(writer, "Song already exists", http.StatusConflict)
+	} else if err.Error() == "incorrect date format" {
+		http.Error(writer, "Invalid date format, use DD.MM.YYYY", http.StatusBadRequest)
+	} else if err.Error() == "failed to get song" {
+		http.Error(writer, "Failed to get song", http.StatusInternalServerError)
+	} else if err.Error() == "failed to get songs text" {
+		http.Error(writer, "Failed to get songs text", http.StatusInternalServerError)
+	} else if err.Error() == "failed to update song" {
+		http.Error(writer, "Failed to update song data", http.StatusInternalServerError)
+	} else if err.Error() == "failed to delete song" {
+		http.Error(writer, "Failed to delete song", http.StatusInternalServerError)
+	} else {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// Обертки над SongByIDHandler и SongTextByIDHandler сделаны для генерации Swagger
+
+// @Summary      Get a song by id
+// @Description  Get a song by its id. The id does not change when the song is edited.
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "Song id (UUID)"
+// @Success      200   {object} SongData  "Song"
+// @Header       200   {string} ETag    "Song version"
+// @Failure      400   {string} string  "Bad request"
+// @Failure      404   {string} string  "Song not found"
+// @Failure      500   {string} string  "Internal server error"
+// @Router       /songs/{id} [get]
+func getSongByIDHandler(w http.ResponseWriter, r *http.Request) {
+	SongByIDHandler(w, r)
+}
+
+// @Summary      Update a song by id
+// @Description  Update release date, text or link of a song by its id, rename it or move it to another group. The body takes the same fields as PATCH /songs except song and group.
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        id        path     string  true   "Song id (UUID)"
+// @Param        song      body     object  true   "Fields to update: releasedate, text, link, newSong, newGroup"
+// @Param        If-Match  header   string  false  "ETag of the song version the update is based on, a comma-separated list or *"
+// @Success      200       {string} string  "Song data updated"
+// @Header       200       {string} ETag    "New song version"
+// @Failure      400       {string} string  "Bad request"
+// @Failure      404       {string} string  "Song not found"
+// @Failure      409       {string} string  "Song already exists"
+// @Failure      412       {string} string  "Song was modified since the passed ETag"
+// @Failure      500       {string} string  "Internal server error"
+// @Router       /songs/{id} [patch]
+func updateSongByIDHandler(w http.ResponseWriter, r *http.Request) {
+	SongByIDHandler(w, r)
+}
+
+// @Summary      Delete a song by id
+// @Description  Move a song to the trash by its id.
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "Song id (UUID)"
+// @Success      200   {string} string  "Song deleted"
+// @Failure      400   {string} string  "Bad request"
+// @Failure      404   {string} string  "Song not found"
+// @Failure      500   {string} string  "Internal server error"
+// @Router       /songs/{id} [delete]
+func deleteSongByIDHandler(w http.ResponseWriter, r *http.Request) {
+	SongByIDHandler(w, r)
+}
+
+// @Summary      Get song text by id
+// @Description  Get the text of a song by its id, or one verse of it.
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        id     path     string  true   "Song id (UUID)"
+// @Param        verse  query    int     false  "Verse number, from 1"
+// @Success      200    {string} string  "Song text"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Song not found"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /songs/{id}/text [get]
+func getSongTextByIDHandler(w http.ResponseWriter, r *http.Request) {
+	SongTextByIDHandler(w, r)
+}
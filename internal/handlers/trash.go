@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"music/internal/services"
+	"net/http"
+	"strings"
+)
+
+// @Summary      Get trashed songs
+// @Description  Get songs deleted with DELETE /songs that have not been purged yet.
+// @Tags         trash
+// @Accept       json
+// @Produce      json
+// @Success      200    {array}  services.TrashedSong  "Trashed songs"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /trash [get]
+func TrashHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "GET" {
+		http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	songs, unexpectedParams, err := services.GetTrash(request.URL.Query())
+	if err != nil {
+		if err.Error() == "unexpected params" {
+			errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
+			http.Error(writer, errorMessage, http.StatusBadRequest)
+			return
+		} else {
+			http.Error(writer, "Failed to get trash", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(200)
+	json.NewEncoder(writer).Encode(songs)
+}
+
+// @Summary      Restore a song
+// @Description  Restore the most recently deleted version of a song from the trash.
+// @Tags         trash
+// @Accept       json
+// @Produce      json
+// @Param        song   query    string  true   "Song name"
+// @Param        group  query    string  true   "Group name"
+// @Param        X-Actor       header   string  false  "Author of the change, 'anonymous' by default"
+// @Param        X-Request-ID  header   string  false  "Request ID for the audit log, generated when omitted"
+// @Success      200    {string} string  "Song restored"
+// @Failure      400    {string} string  "Bad request"
+// @Failure      404    {string} string  "Song is not in trash"
+// @Failure      409    {string} string  "Song already exists"
+// @Failure      500    {string} string  "Internal server error"
+// @Router       /songs/restore [post]
+func RestoreHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "POST" {
+		http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	unexpectedParams, err := services.RestoreSong(request.URL.Query(), requestActor(writer, request))
+	if err != nil {
+		if err.Error() == "unexpected params" {
+			errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
+			http.Error(writer, errorMessage, http.StatusBadRequest)
+			return
+		} else if err.Error() == "song is not in trash" {
+			http.Error(writer, "Song is not in trash", http.StatusNotFound)
+			return
+		} else if err.Error() == "song already exists" {
+			http.Error(writer, "Song already exists", http.StatusConflict)
+			return
+		} else if err.Error() == "failed to restore song" {
+			http.Error(writer, "Failed to restore song", http.StatusInternalServerError)
+			return
+		} else {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	writer.WriteHeader(200)
+	writer.Write([]byte("Song restored"))
+}
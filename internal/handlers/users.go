@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"music/internal/services"
+	"net/http"
+	"strings"
+)
+
+// Пользователь, от имени которого сделан запрос: имя из заголовка X-Actor, пусто - анонимный запрос
+func requestUser(request *http.Request) string {
+	return headerValue(request, actorHeader)
+}
+
+// Обработчик /users
+func UsersHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "GET" {
+		user, unexpectedParams, err := services.GetUser(request.URL.Query())
+		if err != nil {
+			userError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(200)
+		json.NewEncoder(writer).Encode(user)
+		return
+
+	} else if request.Method == "POST" {
+		unexpectedParams, err := services.AddUser(request.URL.Query())
+		if err != nil {
+			userError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("New user added"))
+		return
+	}
+
+	http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+}
+
+// Обработчик /songs/favorites
+func FavoritesHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "POST" {
+		unexpectedParams, err := services.SetFavorite(request.URL.Query(), requestUser(request), true)
+		if err != nil {
+			userError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Song added to favorites"))
+		return
+
+	} else if request.Method == "DELETE" {
+		unexpectedParams, err := services.SetFavorite(request.URL.Query(), requestUser(request), false)
+		if err != nil {
+			userError(writer, err, unexpectedParams)
+			return
+		}
+
+		writer.WriteHeader(200)
+		writer.Write([]byte("Song removed from favorites"))
+		return
+	}
+
+	http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+}
+
+// Обработчик /songs/ratings
+func RatingsHandler(writer http.ResponseWriter, request *http.Request) {
+	var rating services.RatingData
+	var unexpectedParams []string
+	var err error
+
+	if request.Method == "POST" {
+		rating, unexpectedParams, err = services.RateSong(request.URL.Query(), requestUser(request))
+	} else if request.Method == "DELETE" {
+		rating, unexpectedParams, err = services.UnrateSong(request.URL.Query(), requestUser(request))
+	} else {
+		http.Error(writer, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		userError(writer, err, unexpectedParams)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(200)
+	json.NewEncoder(writer).Encode(rating)
+}
+
+// Отвечает ошибкой для обработчиков пользователей, избранного и оценок
+func userError(writer http.ResponseWriter, err error, unexpectedParams []string) {
+	if err.Error() == "unexpected params" {
+		errorMessage := "Unexpected parameters: " + strings.Join(unexpectedParams, ", ")
+		http.Error(writer, errorMessage, http.StatusBadRequest)
+	} else if err.Error() == "song does not exist" {
+		songNotFound(writer, err)
+	} else if err.Error() == "no user" {
+		http.Error(writer, fmt.Sprintf("Pass your user name in the %s header", actorHeader), http.StatusUnauthorized)
+	} else if err.Error() == "unknown user" {
+		http.Error(writer, "User is not registered, add it with POST /users first", http.StatusUnauthorized)
+	} else if err.Error() == "user does not exist" {
+		http.Error(writer, "User does not exist", http.StatusNotFound)
+	} else if err.Error() == "user already exists" {
+		http.Error(writer, "User already exists", http.StatusConflict)
+	} else if err.Error() == "empty user name" {
+		http.Error(writer, "User name must not be empty", http.StatusBadRequest)
+	} else if err.Error() == "failed to add user" {
+		http.Error(writer, "Failed to add user", http.StatusInternalServerError)
+	} else if err.Error() == "failed to get user" {
+		http.Error(writer, "Failed to get user", http.StatusInternalServerError)
+	} else if err.Error() == "failed to update favorites" {
+		http.Error(writer, "Failed to update favorites", http.StatusInternalServerError)
+	} else if err.Error() == "failed to rate song" {
+		http.Error(writer, "Failed to rate song", http.StatusInternalServerError)
+	} else {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// Обертки над обработчиками сделаны для генерации Swagger
+
+// @Summary      Get a user
+// @Description  Get a user with the number of favorite and rated songs.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        user  query    string  true  "User name"
+// @Success      200   {object} services.UserData  "User"
+// @Failure      400   {string} string  "Bad request"
+// @Failure      404   {string} string  "User not found"
+// @Failure      500   {string} string  "Internal server error"
+// @Router       /users [get]
+func getUserHandler(w http.ResponseWriter, r *http.Request) {
+	UsersHandler(w, r)
+}
+
+// @Summary      Register a user
+// @Description  Register a user. Requests are made on behalf of a user by passing the user name in the X-Actor header.
+// @Description  The header is not authenticated: any client can read and change favorites, ratings and plays of any user, so the API must not be exposed to untrusted clients.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        user  query    string  true  "User name"
+// @Success      200   {string} string  "User added"
+// @Failure      400   {string} string  "Bad request"
+// @Failure      409   {string} string  "User already exists"
+// @Failure      500   {string} string  "Internal server error"
+// @Router       /users [post]
+func addUserHandler(w http.ResponseWriter, r *http.Request) {
+	UsersHandler(w, r)
+}
+
+// @Summary      Add a song to favorites
+// @Description  Add a song to favorites of the user from the X-Actor header. Adding it again changes nothing.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        song     query    string  true  "Song name"
+// @Param        group    query    string  true  "Group name"
+// @Param        X-Actor  header   string  true  "Registered user name, the header is not authenticated"
+// @Success      200      {string} string  "Song added to favorites"
+// @Failure      400      {string} string  "Bad request"
+// @Failure      401      {string} string  "User is not passed or not registered"
+// @Failure      404      {string} string  "Song not found"
+// @Failure      500      {string} string  "Internal server error"
+// @Router       /songs/favorites [post]
+func addFavoriteHandler(w http.ResponseWriter, r *http.Request) {
+	FavoritesHandler(w, r)
+}
+
+// @Summary      Remove a song from favorites
+// @Description  Remove a song from favorites of the user from the X-Actor header.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        song     query    string  true  "Song name"
+// @Param        group    query    string  true  "Group name"
+// @Param        X-Actor  header   string  true  "Registered user name, the header is not authenticated"
+// @Success      200      {string} string  "Song removed from favorites"
+// @Failure      400      {string} string  "Bad request"
+// @Failure      401      {string} string  "User is not passed or not registered"
+// @Failure      404      {string} string  "Song not found"
+// @Failure      500      {string} string  "Internal server error"
+// @Router       /songs/favorites [delete]
+func removeFavoriteHandler(w http.ResponseWriter, r *http.Request) {
+	FavoritesHandler(w, r)
+}
+
+// @Summary      Rate a song
+// @Description  Rate a song from 1 to 5 on behalf of the user from the X-Actor header. A new rating replaces the previous one.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        song     query    string  true  "Song name"
+// @Param        group    query    string  true  "Group name"
+// @Param        rating   query    int     true  "Rating from 1 to 5"
+// @Param        X-Actor  header   string  true  "Registered user name, the header is not authenticated"
+// @Success      200      {object} services.RatingData  "Average rating and number of ratings of the song"
+// @Failure      400      {string} string  "Bad request"
+// @Failure      401      {string} string  "User is not passed or not registered"
+// @Failure      404      {string} string  "Song not found"
+// @Failure      500      {string} string  "Internal server error"
+// @Router       /songs/ratings [post]
+func rateSongHandler(w http.ResponseWriter, r *http.Request) {
+	RatingsHandler(w, r)
+}
+
+// @Summary      Remove a rating
+// @Description  Remove the rating of the user from the X-Actor header.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        song     query    string  true  "Song name"
+// @Param        group    query    string  true  "Group name"
+// @Param        X-Actor  header   string  true  "Registered user name, the header is not authenticated"
+// @Success      200      {object} services.RatingData  "Average rating and number of ratings of the song"
+// @Failure      400      {string} string  "Bad request"
+// @Failure      401      {string} string  "User is not passed or not registered"
+// @Failure      404      {string} string  "Song not found"
+// @Failure      500      {string} string  "Internal server error"
+// @Router       /songs/ratings [delete]
+func unrateSongHandler(w http.ResponseWriter, r *http.Request) {
+	RatingsHandler(w, r)
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"music/internal/database"
+	"music/tools"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Альбом с песнями
+type AlbumData struct {
+	Album       string      `json:"album"`
+	Group       string      `json:"group"`
+	ReleaseDate string      `json:"releaseDate"`
+	Type        string      `json:"type"`
+	Tracks      []TrackData `json:"tracks"`
+}
+
+// Песня на альбоме
+type TrackData struct {
+	Song  string `json:"song"`
+	Disc  int    `json:"disc"`
+	Track int    `json:"track"`
+}
+
+// Альбом песни из music info API
+type SongAlbumData struct {
+	Title       string `json:"title"`
+	ReleaseDate string `json:"releaseDate"`
+	Type        string `json:"type"`
+	Disc        int    `json:"disc"`
+	Track       int    `json:"track"`
+}
+
+// Разбирает дату выхода и тип альбома, пустые значения допустимы
+func parseAlbumFields(releaseDate, albumType string) (time.Time, error) {
+	date := time.Time{}
+	if releaseDate != "" {
+		var err error
+		date, err = time.Parse("2.1.2006", releaseDate)
+		if err != nil {
+			tools.Logger.Info(fmt.Sprintf("Invalid date format: %s", releaseDate))
+			return date, errors.New("incorrect date format")
+		}
+	}
+	if albumType != "" && !database.IsAlbumType(albumType) {
+		tools.Logger.Info(fmt.Sprintf("Invalid album type: %s", albumType))
+		return date, errors.New("incorrect album type")
+	}
+	return date, nil
+}
+
+// Разбирает номер диска или трека
+func parseTrackNumber(params url.Values, param string) (int, error) {
+	number, err := strconv.Atoi(params.Get(param))
+	if err != nil || number < 1 {
+		tools.Logger.Info(fmt.Sprintf("Invalid '%s' format passed: %s", param, params.Get(param)))
+		errorMessage := fmt.Sprintf("'%s' requires a positive number", param)
+		return 0, errors.New(errorMessage)
+	}
+	return number, nil
+}
+
+// Получает список альбомов
+func GetAlbums(params url.Values) ([]AlbumData, []string, error) {
+	albums := []AlbumData{}
+
+	expectedParams := map[string]bool{
+		"album": true,
+		"group": true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, map[string]bool{})
+	if err != nil {
+		return albums, unexpectedParams, err
+	}
+	for param := range params {
+		if len(params[param]) != 1 {
+			tools.Logger.Info(fmt.Sprintf("To many '%s' parameters was passed\n", param))
+			errorMessage := fmt.Sprintf("'%s' requires only 1 value", param)
+			return albums, unexpectedParams, errors.New(errorMessage)
+		}
+	}
+
+	found, err := repository.ListAlbums(params.Get("group"), params.Get("album"))
+	if err != nil {
+		err = errors.New("failed to get albums")
+		return albums, unexpectedParams, err
+	}
+
+	for _, album := range found {
+		temp := AlbumData{
+			Album:  album.Title,
+			Group:  album.Group,
+			Type:   album.Type,
+			Tracks: []TrackData{},
+		}
+		if !album.ReleaseDate.IsZero() {
+			temp.ReleaseDate = album.ReleaseDate.Format("02.01.2006")
+		}
+		for _, track := range album.Tracks {
+			temp.Tracks = append(temp.Tracks, TrackData{Song: track.Song, Disc: track.Disc, Track: track.Track})
+		}
+		albums = append(albums, temp)
+	}
+
+	return albums, unexpectedParams, nil
+}
+
+// Добавляет новый альбом
+func AddAlbum(params url.Values) ([]string, error) {
+	expectedParams := map[string]bool{
+		"album":       true,
+		"group":       true,
+		"releasedate": true,
+		"type":        true,
+	}
+	requiredParams := map[string]bool{
+		"album": true,
+		"group": true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	album := database.Album{
+		Title: params.Get("album"),
+		Group: params.Get("group"),
+		Type:  params.Get("type"),
+	}
+	album.ReleaseDate, err = parseAlbumFields(params.Get("releasedate"), album.Type)
+	if err != nil {
+		return unexpectedParams, err
+	}
+	if album.Type == "" {
+		album.Type = database.AlbumLP
+	}
+
+	err = repository.AddAlbum(album)
+	if err != nil {
+		if err.Error() == "album already exists" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to add album")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Обновляет дату выхода и тип альбома
+func UpdateAlbum(params map[string]string) ([]string, error) {
+	expectedParams := map[string]bool{
+		"album":       true,
+		"group":       true,
+		"releasedate": true,
+		"type":        true,
+	}
+
+	unexpectedParams := []string{}
+
+	// Проверка на лишние параметры
+	for param := range params {
+		if _, ok := expectedParams[param]; !ok {
+			unexpectedParams = append(unexpectedParams, param)
+		}
+	}
+
+	// Если нашли лишние параметры
+	if len(unexpectedParams) != 0 {
+		tools.Logger.Info(fmt.Sprintf("Unexpected parameters passed: %s", strings.Join(unexpectedParams, ", ")))
+		err := errors.New("unexpected params")
+		return unexpectedParams, err
+	}
+
+	// Проверка на обязательные параметры
+	for _, param := range []string{"album", "group"} {
+		if _, ok := params[param]; !ok {
+			tools.Logger.Info(fmt.Sprintf("Required parameter '%s' was not passed\n", param))
+			errorMessage := fmt.Sprintf("'%s' parameter is required", param)
+			err := errors.New(errorMessage)
+			return unexpectedParams, err
+		}
+	}
+
+	album := database.Album{
+		Title: params["album"],
+		Group: params["group"],
+		Type:  params["type"],
+	}
+	var err error
+	album.ReleaseDate, err = parseAlbumFields(params["releasedate"], album.Type)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	err = repository.UpdateAlbum(album)
+	if err != nil {
+		if err.Error() == "album does not exist" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to update album")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Удаляет альбом
+func DeleteAlbum(params url.Values) ([]string, error) {
+	requiredParams := map[string]bool{
+		"album": true,
+		"group": true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	err = repository.DeleteAlbum(params.Get("album"), params.Get("group"))
+	if err != nil {
+		if err.Error() == "album does not exist" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to delete album")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Добавляет песню на альбом
+func AddTrack(params url.Values) ([]string, error) {
+	expectedParams := map[string]bool{
+		"album": true,
+		"group": true,
+		"song":  true,
+		"disc":  true,
+		"track": true,
+	}
+	requiredParams := map[string]bool{
+		"album": true,
+		"group": true,
+		"song":  true,
+		"track": true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	disc := 1
+	if params.Has("disc") {
+		disc, err = parseTrackNumber(params, "disc")
+		if err != nil {
+			return unexpectedParams, err
+		}
+	}
+	track, err := parseTrackNumber(params, "track")
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	album, group, song := params.Get("album"), params.Get("group"), params.Get("song")
+	err = repository.AddTrack(album, group, song, disc, track)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return unexpectedParams, songNotFound(song, group)
+		} else if err.Error() == "album does not exist" ||
+			err.Error() == "song is already on album" ||
+			err.Error() == "track is taken" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to add track")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Убирает песню с альбома
+func RemoveTrack(params url.Values) ([]string, error) {
+	requiredParams := map[string]bool{
+		"album": true,
+		"group": true,
+		"song":  true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	album, group, song := params.Get("album"), params.Get("group"), params.Get("song")
+	err = repository.RemoveTrack(album, group, song)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return unexpectedParams, songNotFound(song, group)
+		} else if err.Error() == "album does not exist" || err.Error() == "song is not on album" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to remove track")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Альбом песни из music info API, nil - данных нет или они неполные
+func songAlbum(data *SongAlbumData) *database.SongAlbum {
+	if data == nil || data.Title == "" || data.Track < 1 || data.Disc < 0 {
+		return nil
+	}
+
+	album := &database.SongAlbum{
+		Title: data.Title,
+		Type:  data.Type,
+		Disc:  data.Disc,
+		Track: data.Track,
+	}
+	if album.Disc == 0 {
+		album.Disc = 1
+	}
+	if !database.IsAlbumType(album.Type) {
+		album.Type = database.AlbumLP
+	}
+	album.ReleaseDate, _ = time.Parse("02.01.2006", data.ReleaseDate)
+	return album
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"music/internal/database"
+	"music/tools"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Запись журнала аудита
+type AuditEntry struct {
+	Song      string          `json:"song"`
+	Group     string          `json:"group"`
+	Operation string          `json:"operation"`
+	Actor     string          `json:"actor"`
+	RequestID string          `json:"requestId"`
+	Before    json.RawMessage `json:"before" swaggertype:"object"`
+	After     json.RawMessage `json:"after" swaggertype:"object"`
+	CreatedAt string          `json:"createdAt"`
+}
+
+// Разбирает границу периода: момент в RFC 3339 или дату DD.MM.YYYY.
+// Для даты в качестве верхней границы берется начало следующего дня
+func parseAuditTime(value string, upper bool) (time.Time, error) {
+	moment, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return moment, nil
+	}
+	date, err := time.ParseInLocation("2.1.2006", value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upper {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date, nil
+}
+
+// Получает журнал аудита
+func GetAudit(params url.Values) ([]AuditEntry, []string, error) {
+	entries := []AuditEntry{}
+
+	expectedParams := map[string]bool{
+		"song":  true,
+		"group": true,
+		"actor": true,
+		"from":  true,
+		"to":    true,
+		"limit": true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, map[string]bool{})
+	if err != nil {
+		return entries, unexpectedParams, err
+	}
+	for param := range params {
+		if len(params[param]) != 1 {
+			tools.Logger.Info(fmt.Sprintf("To many '%s' parameters was passed\n", param))
+			errorMessage := fmt.Sprintf("'%s' requires only 1 value", param)
+			return entries, unexpectedParams, errors.New(errorMessage)
+		}
+	}
+
+	filter := database.AuditFilter{
+		Song:  params.Get("song"),
+		Group: params.Get("group"),
+		Actor: params.Get("actor"),
+		Limit: database.DefaultLimit,
+	}
+
+	if params.Has("limit") {
+		limit, err := strconv.Atoi(params.Get("limit"))
+		if err != nil || limit < 1 || limit > maxLimit {
+			tools.Logger.Info(fmt.Sprintf("Invalid 'limit' format passed: %s", params.Get("limit")))
+			errorMessage := fmt.Sprintf("'limit' requires a number from 1 to %d", maxLimit)
+			return entries, unexpectedParams, errors.New(errorMessage)
+		}
+		filter.Limit = limit
+	}
+
+	for _, param := range []string{"from", "to"} {
+		if !params.Has(param) {
+			continue
+		}
+		moment, err := parseAuditTime(params.Get(param), param == "to")
+		if err != nil {
+			tools.Logger.Info(fmt.Sprintf("Invalid '%s' format passed: %s", param, params.Get(param)))
+			errorMessage := fmt.Sprintf("'%s' requires a time in RFC 3339 or a date in format DD.MM.YYYY", param)
+			return entries, unexpectedParams, errors.New(errorMessage)
+		}
+		if param == "from" {
+			filter.From = moment
+		} else {
+			filter.To = moment
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		tools.Logger.Info("'from' is not before 'to'")
+		return entries, unexpectedParams, errors.New("'from' must be before 'to'")
+	}
+
+	found, err := repository.ListAudit(filter)
+	if err != nil {
+		err = errors.New("failed to get audit log")
+		return entries, unexpectedParams, err
+	}
+
+	for _, entry := range found {
+		entries = append(entries, AuditEntry{
+			Song:      entry.Song,
+			Group:     entry.Group,
+			Operation: entry.Operation,
+			Actor:     entry.Actor,
+			RequestID: entry.RequestID,
+			Before:    entry.Before,
+			After:     entry.After,
+			CreatedAt: entry.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return entries, unexpectedParams, nil
+}
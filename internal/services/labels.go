@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"music/internal/database"
+	"music/tools"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Жанры и теги песни
+type LabelsData struct {
+	Genres []string `json:"genres"`
+	Tags   []string `json:"tags"`
+}
+
+// Метка и число песен с ней
+type LabelData struct {
+	Name  string `json:"name"`
+	Songs int    `json:"songs"`
+}
+
+// Наибольшая длина названия метки, как у колонок name
+const maxLabelName = 64
+
+// Приводит названия меток к хранимому виду, пустые и слишком длинные названия недопустимы
+func normalizeLabels(names []string) ([]string, error) {
+	labels := []string{}
+	for _, name := range names {
+		label := database.NormalizeLabel(name)
+		if label == "" {
+			tools.Logger.Info("Empty label name passed")
+			return labels, errors.New("empty label")
+		}
+		if utf8.RuneCountInString(label) > maxLabelName {
+			tools.Logger.Info(fmt.Sprintf("Too long label name passed: %s", label))
+			return labels, errors.New("label too long")
+		}
+		if !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	return labels, nil
+}
+
+// Разбирает жанры и теги из параметров genre и tag, названия перечисляются через запятую
+func labelParams(params url.Values) (map[database.LabelKind][]string, error) {
+	labels := map[database.LabelKind][]string{}
+	for _, kind := range []database.LabelKind{database.LabelGenre, database.LabelTag} {
+		if !params.Has(string(kind)) {
+			continue
+		}
+		names, err := normalizeLabels(strings.Split(params.Get(string(kind)), ","))
+		if err != nil {
+			return labels, err
+		}
+		labels[kind] = names
+	}
+	if len(labels) == 0 {
+		tools.Logger.Info("Neither 'genre' nor 'tag' was passed")
+		return labels, errors.New("no labels")
+	}
+	return labels, nil
+}
+
+// Получает жанры и теги песни
+func GetSongLabels(params url.Values) (LabelsData, []string, error) {
+	labels := LabelsData{Genres: []string{}, Tags: []string{}}
+
+	requiredParams := map[string]bool{
+		"song":  true,
+		"group": true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return labels, unexpectedParams, err
+	}
+
+	song, group := params.Get("song"), params.Get("group")
+	found, err := repository.SongLabels(song, group)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return labels, unexpectedParams, songNotFound(song, group)
+		}
+		err = errors.New("failed to get labels")
+		return labels, unexpectedParams, err
+	}
+
+	labels.Genres = found[database.LabelGenre]
+	labels.Tags = found[database.LabelTag]
+	return labels, unexpectedParams, nil
+}
+
+// Добавляет песне жанры и теги
+func AttachLabels(params url.Values) ([]string, error) {
+	return changeLabels(params, repository.AttachLabels)
+}
+
+// Убирает у песни жанры и теги
+func DetachLabels(params url.Values) ([]string, error) {
+	return changeLabels(params, repository.DetachLabels)
+}
+
+// Применяет change к жанрам и тегам из параметров запроса
+func changeLabels(params url.Values, change func(song, group string, labels map[database.LabelKind][]string) error) ([]string, error) {
+	expectedParams := map[string]bool{
+		"song":  true,
+		"group": true,
+		"genre": true,
+		"tag":   true,
+	}
+	requiredParams := map[string]bool{
+		"song":  true,
+		"group": true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	labels, err := labelParams(params)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	song, group := params.Get("song"), params.Get("group")
+	err = change(song, group, labels)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return unexpectedParams, songNotFound(song, group)
+		}
+		err = errors.New("failed to update labels")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Параметры GET /songs, которые при разметке по фильтру не имеют смысла
+var bulkIgnoredParams = []string{"page", "onpage", "limit", "cursor", "sort"}
+
+// Добавляет жанры и теги всем песням, подходящим под фильтр. Фильтр задается как в GET /songs
+// без пагинации и сортировки и должен содержать хотя бы одно условие
+func BulkLabel(params url.Values, user string, labels LabelsData) (int64, []string, error) {
+	unexpectedParams := []string{}
+	for _, param := range bulkIgnoredParams {
+		if params.Has(param) {
+			unexpectedParams = append(unexpectedParams, param)
+		}
+	}
+	if len(unexpectedParams) != 0 {
+		tools.Logger.Info(fmt.Sprintf("Unexpected parameters passed: %s", strings.Join(unexpectedParams, ", ")))
+		err := errors.New("unexpected params")
+		return 0, unexpectedParams, err
+	}
+
+	filter, unexpectedParams, err := songFilter(params, user)
+	if err != nil {
+		return 0, unexpectedParams, err
+	}
+	if len(filter.Conditions) == 0 && filter.FavoriteOf == "" {
+		tools.Logger.Info("Bulk labeling without filter conditions")
+		return 0, unexpectedParams, errors.New("no filter")
+	}
+
+	genres, err := normalizeLabels(labels.Genres)
+	if err != nil {
+		return 0, unexpectedParams, err
+	}
+	tags, err := normalizeLabels(labels.Tags)
+	if err != nil {
+		return 0, unexpectedParams, err
+	}
+	if len(genres) == 0 && len(tags) == 0 {
+		tools.Logger.Info("Neither genres nor tags were passed")
+		return 0, unexpectedParams, errors.New("no labels")
+	}
+
+	labelsByKind := map[database.LabelKind][]string{}
+	if len(genres) != 0 {
+		labelsByKind[database.LabelGenre] = genres
+	}
+	if len(tags) != 0 {
+		labelsByKind[database.LabelTag] = tags
+	}
+
+	matched, err := repository.LabelSongs(filter, labelsByKind)
+	if err != nil {
+		err = errors.New("failed to update labels")
+		return 0, unexpectedParams, err
+	}
+
+	return matched, unexpectedParams, nil
+}
+
+// Получает список меток вида kind с числом песен
+func GetLabels(params url.Values, kind database.LabelKind) ([]LabelData, []string, error) {
+	labels := []LabelData{}
+
+	unexpectedParams, err := checkParams(params, map[string]bool{}, map[string]bool{})
+	if err != nil {
+		return labels, unexpectedParams, err
+	}
+
+	found, err := repository.ListLabels(kind)
+	if err != nil {
+		err = errors.New("failed to get labels")
+		return labels, unexpectedParams, err
+	}
+
+	for _, label := range found {
+		labels = append(labels, LabelData{Name: label.Name, Songs: label.Songs})
+	}
+	return labels, unexpectedParams, nil
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"music/internal/database"
+	"music/tools"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Наибольшая длина подписи ссылки, как у колонки label
+const maxLinkLabel = 255
+
+// Ссылка песни
+type LinkData struct {
+	Provider string `json:"provider"`
+	URL      string `json:"url"`
+	Label    string `json:"label,omitempty"`
+}
+
+// Ссылки новой песни из ответа music info API: основная ссылка и список links.
+// Неподходящие ссылки пропускаются, песня добавляется без них
+func songLinks(data SongData) []database.Link {
+	links := []database.Link{}
+	for _, link := range append([]LinkData{{URL: data.Link}}, data.Links...) {
+		if link.URL == "" {
+			continue
+		}
+		provider, canonical, err := database.NormalizeLink(link.URL)
+		if err != nil {
+			tools.Logger.Info(fmt.Sprintf("Skipped invalid link from song info: %s", link.URL))
+			continue
+		}
+		links = append(links, database.Link{Provider: provider, URL: canonical, Label: strings.TrimSpace(link.Label)})
+	}
+	return links
+}
+
+// Приводит ссылку из параметра url к каноническому виду
+func linkParam(params url.Values) (database.LinkProvider, string, error) {
+	provider, canonical, err := database.NormalizeLink(params.Get("url"))
+	if err != nil {
+		tools.Logger.Info(fmt.Sprintf("Invalid 'url' passed: %s", params.Get("url")))
+		return provider, canonical, errors.New("'url' requires an http or https link")
+	}
+	return provider, canonical, nil
+}
+
+// Получает ссылки песни
+func GetSongLinks(params url.Values) ([]LinkData, []string, error) {
+	links := []LinkData{}
+
+	requiredParams := map[string]bool{
+		"song":  true,
+		"group": true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return links, unexpectedParams, err
+	}
+
+	song, group := params.Get("song"), params.Get("group")
+	found, err := repository.SongLinks(song, group)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return links, unexpectedParams, songNotFound(song, group)
+		}
+		err = errors.New("failed to get links")
+		return links, unexpectedParams, err
+	}
+
+	for _, link := range found {
+		links = append(links, LinkData{Provider: string(link.Provider), URL: link.URL, Label: link.Label})
+	}
+	return links, unexpectedParams, nil
+}
+
+// Добавляет песне ссылку. Сервис определяется по ссылке, возвращается ссылка в каноническом виде
+func AddLink(params url.Values) (LinkData, []string, error) {
+	expectedParams := map[string]bool{
+		"song":  true,
+		"group": true,
+		"url":   true,
+		"label": true,
+	}
+	requiredParams := map[string]bool{
+		"song":  true,
+		"group": true,
+		"url":   true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, requiredParams)
+	if err != nil {
+		return LinkData{}, unexpectedParams, err
+	}
+
+	provider, canonical, err := linkParam(params)
+	if err != nil {
+		return LinkData{}, unexpectedParams, err
+	}
+	label := strings.TrimSpace(params.Get("label"))
+	if utf8.RuneCountInString(label) > maxLinkLabel {
+		tools.Logger.Info(fmt.Sprintf("Too long 'label' passed: %s", label))
+		errorMessage := fmt.Sprintf("'label' must be at most %d characters", maxLinkLabel)
+		return LinkData{}, unexpectedParams, errors.New(errorMessage)
+	}
+
+	song, group := params.Get("song"), params.Get("group")
+	link := database.Link{Provider: provider, URL: canonical, Label: label}
+	err = repository.AddLink(song, group, link)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return LinkData{}, unexpectedParams, songNotFound(song, group)
+		} else if err.Error() == "link already exists" {
+			return LinkData{}, unexpectedParams, err
+		}
+		err = errors.New("failed to add link")
+		return LinkData{}, unexpectedParams, err
+	}
+
+	return LinkData{Provider: string(provider), URL: canonical, Label: label}, unexpectedParams, nil
+}
+
+// Убирает ссылку песни. Ссылка может быть передана в любом виде, который приводится к тому же каноническому
+func DeleteLink(params url.Values) ([]string, error) {
+	requiredParams := map[string]bool{
+		"song":  true,
+		"group": true,
+		"url":   true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	_, canonical, err := linkParam(params)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	song, group := params.Get("song"), params.Get("group")
+	err = repository.DeleteLink(song, group, canonical)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return unexpectedParams, songNotFound(song, group)
+		} else if err.Error() == "link does not exist" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to delete link")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
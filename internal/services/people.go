@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"music/internal/database"
+	"music/tools"
+	"net/url"
+	"time"
+)
+
+// Участие человека в группе
+type MemberData struct {
+	Person string `json:"person"`
+	Group  string `json:"group"`
+	Role   string `json:"role"`
+	// Пусто - период открыт с этой стороны
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Разбирает необязательную дату из параметра
+func parseOptionalDate(params url.Values, param string) (time.Time, error) {
+	if !params.Has(param) {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse("2.1.2006", params.Get(param))
+	if err != nil {
+		tools.Logger.Info(fmt.Sprintf("Invalid '%s' format passed: %s", param, params.Get(param)))
+		errorMessage := fmt.Sprintf("'%s' requires a date in format DD.MM.YYYY", param)
+		return date, errors.New(errorMessage)
+	}
+	return date, nil
+}
+
+// Форматирует дату, нулевая дата - пустая строка
+func formatOptionalDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format("02.01.2006")
+}
+
+func toMemberData(memberships []database.Membership) []MemberData {
+	members := []MemberData{}
+	for _, membership := range memberships {
+		members = append(members, MemberData{
+			Person: membership.Person,
+			Group:  membership.Group,
+			Role:   membership.Role,
+			From:   formatOptionalDate(membership.From),
+			To:     formatOptionalDate(membership.To),
+		})
+	}
+	return members
+}
+
+// Получает состав группы на дату, по умолчанию - на сегодня
+func GetLineup(params url.Values) ([]MemberData, []string, error) {
+	expectedParams := map[string]bool{
+		"group": true,
+		"date":  true,
+	}
+	requiredParams := map[string]bool{
+		"group": true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, requiredParams)
+	if err != nil {
+		return []MemberData{}, unexpectedParams, err
+	}
+
+	date, err := parseOptionalDate(params, "date")
+	if err != nil {
+		return []MemberData{}, unexpectedParams, err
+	}
+	if date.IsZero() {
+		year, month, day := time.Now().Date()
+		date = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	lineup, err := repository.ListLineup(params.Get("group"), date)
+	if err != nil {
+		if err.Error() == "group does not exist" {
+			return []MemberData{}, unexpectedParams, err
+		}
+		err = errors.New("failed to get lineup")
+		return []MemberData{}, unexpectedParams, err
+	}
+
+	return toMemberData(lineup), unexpectedParams, nil
+}
+
+// Добавляет человека в группу
+func AddMember(params url.Values) ([]string, error) {
+	expectedParams := map[string]bool{
+		"group":  true,
+		"person": true,
+		"role":   true,
+		"from":   true,
+		"to":     true,
+	}
+	requiredParams := map[string]bool{
+		"group":  true,
+		"person": true,
+		"role":   true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	membership := database.Membership{
+		Person: params.Get("person"),
+		Group:  params.Get("group"),
+		Role:   params.Get("role"),
+	}
+	for _, param := range []string{"person", "group", "role"} {
+		if params.Get(param) == "" {
+			tools.Logger.Info(fmt.Sprintf("Empty '%s' parameter was passed\n", param))
+			errorMessage := fmt.Sprintf("'%s' must not be empty", param)
+			return unexpectedParams, errors.New(errorMessage)
+		}
+	}
+
+	membership.From, err = parseOptionalDate(params, "from")
+	if err != nil {
+		return unexpectedParams, err
+	}
+	membership.To, err = parseOptionalDate(params, "to")
+	if err != nil {
+		return unexpectedParams, err
+	}
+	if !membership.From.IsZero() && !membership.To.IsZero() && membership.From.After(membership.To) {
+		tools.Logger.Info("'from' is after 'to'")
+		return unexpectedParams, errors.New("'from' must not be after 'to'")
+	}
+
+	err = repository.AddMembership(membership)
+	if err != nil {
+		if err.Error() == "membership already exists" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to add member")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Удаляет человека из состава группы
+func DeleteMember(params url.Values) ([]string, error) {
+	expectedParams := map[string]bool{
+		"group":  true,
+		"person": true,
+		"role":   true,
+		"from":   true,
+	}
+	requiredParams := map[string]bool{
+		"group":  true,
+		"person": true,
+		"role":   true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	from, err := parseOptionalDate(params, "from")
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	err = repository.DeleteMembership(params.Get("person"), params.Get("group"), params.Get("role"), from)
+	if err != nil {
+		if err.Error() == "membership does not exist" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to delete member")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Получает группы, в которых играл человек
+func GetPersonGroups(params url.Values) ([]MemberData, []string, error) {
+	requiredParams := map[string]bool{
+		"person": true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return []MemberData{}, unexpectedParams, err
+	}
+
+	memberships, err := repository.ListMemberships(params.Get("person"))
+	if err != nil {
+		if err.Error() == "person does not exist" {
+			return []MemberData{}, unexpectedParams, err
+		}
+		err = errors.New("failed to get person groups")
+		return []MemberData{}, unexpectedParams, err
+	}
+
+	return toMemberData(memberships), unexpectedParams, nil
+}
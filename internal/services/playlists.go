@@ -0,0 +1,303 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"music/internal/database"
+	"music/tools"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Плейлист в списке плейлистов
+type PlaylistData struct {
+	Playlist  string `json:"playlist"`
+	CreatedAt string `json:"createdAt"`
+	Songs     int    `json:"songs"`
+}
+
+// Плейлист с песнями по порядку
+type PlaylistEntriesData struct {
+	PlaylistData
+	Entries []EntryData `json:"entries"`
+}
+
+// Песня на позиции плейлиста
+type EntryData struct {
+	Position int      `json:"position"`
+	Song     SongData `json:"song"`
+}
+
+// Перестановка песни в плейлисте
+type MoveEntryData struct {
+	Playlist string `json:"playlist"`
+	Position int    `json:"position"`
+	To       int    `json:"to"`
+}
+
+// Проверяет название плейлиста
+func checkPlaylistName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		tools.Logger.Info("Empty playlist name passed")
+		return errors.New("empty playlist name")
+	}
+	return nil
+}
+
+// Получает плейлисты без песен
+func GetPlaylists(params url.Values) ([]PlaylistData, []string, error) {
+	playlists := []PlaylistData{}
+
+	unexpectedParams, err := checkParams(params, map[string]bool{}, map[string]bool{})
+	if err != nil {
+		return playlists, unexpectedParams, err
+	}
+
+	found, err := repository.ListPlaylists()
+	if err != nil {
+		err = errors.New("failed to get playlists")
+		return playlists, unexpectedParams, err
+	}
+
+	for _, playlist := range found {
+		playlists = append(playlists, PlaylistData{
+			Playlist:  playlist.Name,
+			CreatedAt: playlist.CreatedAt.Format(time.RFC3339),
+			Songs:     playlist.Songs,
+		})
+	}
+	return playlists, unexpectedParams, nil
+}
+
+// Получает плейлист с песнями по порядку
+func GetPlaylist(params url.Values) (PlaylistEntriesData, []string, error) {
+	playlist := PlaylistEntriesData{Entries: []EntryData{}}
+
+	requiredParams := map[string]bool{
+		"playlist": true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return playlist, unexpectedParams, err
+	}
+
+	found, err := repository.GetPlaylist(params.Get("playlist"))
+	if err != nil {
+		if err.Error() == "playlist does not exist" {
+			return playlist, unexpectedParams, err
+		}
+		err = errors.New("failed to get playlists")
+		return playlist, unexpectedParams, err
+	}
+
+	playlist.Playlist = found.Name
+	playlist.CreatedAt = found.CreatedAt.Format(time.RFC3339)
+	playlist.Songs = found.Songs
+	for _, entry := range found.Entries {
+		song := DateToString([]database.SongData{entry.Song})[0]
+		playlist.Entries = append(playlist.Entries, EntryData{Position: entry.Position, Song: song})
+	}
+	return playlist, unexpectedParams, nil
+}
+
+// Создает пустой плейлист
+func AddPlaylist(params url.Values) ([]string, error) {
+	requiredParams := map[string]bool{
+		"playlist": true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	name := params.Get("playlist")
+	if err = checkPlaylistName(name); err != nil {
+		return unexpectedParams, err
+	}
+
+	err = repository.AddPlaylist(name)
+	if err != nil {
+		if err.Error() == "playlist already exists" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to add playlist")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Переименовывает плейлист
+func RenamePlaylist(params map[string]string) ([]string, error) {
+	expectedParams := map[string]bool{
+		"playlist": true,
+		"name":     true,
+	}
+
+	unexpectedParams := []string{}
+
+	// Проверка на лишние параметры
+	for param := range params {
+		if _, ok := expectedParams[param]; !ok {
+			unexpectedParams = append(unexpectedParams, param)
+		}
+	}
+
+	// Если нашли лишние параметры
+	if len(unexpectedParams) != 0 {
+		tools.Logger.Info(fmt.Sprintf("Unexpected parameters passed: %s", strings.Join(unexpectedParams, ", ")))
+		err := errors.New("unexpected params")
+		return unexpectedParams, err
+	}
+
+	// Проверка на обязательные параметры
+	for _, param := range []string{"playlist", "name"} {
+		if _, ok := params[param]; !ok {
+			tools.Logger.Info(fmt.Sprintf("Required parameter '%s' was not passed\n", param))
+			errorMessage := fmt.Sprintf("'%s' parameter is required", param)
+			err := errors.New(errorMessage)
+			return unexpectedParams, err
+		}
+	}
+
+	if err := checkPlaylistName(params["name"]); err != nil {
+		return unexpectedParams, err
+	}
+
+	err := repository.RenamePlaylist(params["playlist"], params["name"])
+	if err != nil {
+		if err.Error() == "playlist does not exist" || err.Error() == "playlist already exists" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to rename playlist")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Удаляет плейлист
+func DeletePlaylist(params url.Values) ([]string, error) {
+	requiredParams := map[string]bool{
+		"playlist": true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	err = repository.DeletePlaylist(params.Get("playlist"))
+	if err != nil {
+		if err.Error() == "playlist does not exist" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to delete playlist")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Ставит песню в плейлист, без позиции - в конец. Возвращает позицию песни
+func AddEntry(params url.Values) (int, []string, error) {
+	expectedParams := map[string]bool{
+		"playlist": true,
+		"song":     true,
+		"group":    true,
+		"position": true,
+	}
+	requiredParams := map[string]bool{
+		"playlist": true,
+		"song":     true,
+		"group":    true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, requiredParams)
+	if err != nil {
+		return 0, unexpectedParams, err
+	}
+
+	position := 0
+	if params.Has("position") {
+		position, err = parseTrackNumber(params, "position")
+		if err != nil {
+			return 0, unexpectedParams, err
+		}
+	}
+
+	song, group := params.Get("song"), params.Get("group")
+	position, err = repository.AddEntry(params.Get("playlist"), song, group, position)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return 0, unexpectedParams, songNotFound(song, group)
+		} else if err.Error() == "playlist does not exist" || err.Error() == "position out of range" {
+			return 0, unexpectedParams, err
+		}
+		err = errors.New("failed to add entry")
+		return 0, unexpectedParams, err
+	}
+
+	return position, unexpectedParams, nil
+}
+
+// Убирает песню с позиции плейлиста
+func RemoveEntry(params url.Values) ([]string, error) {
+	requiredParams := map[string]bool{
+		"playlist": true,
+		"position": true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	position, err := parseTrackNumber(params, "position")
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	err = repository.RemoveEntry(params.Get("playlist"), position)
+	if err != nil {
+		if err.Error() == "playlist does not exist" || err.Error() == "entry does not exist" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to remove entry")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Переставляет песню на другую позицию плейлиста
+func MoveEntry(data MoveEntryData) error {
+	if data.Playlist == "" {
+		tools.Logger.Info("Required parameter 'playlist' was not passed\n")
+		return errors.New("'playlist' parameter is required")
+	}
+	if data.Position < 1 {
+		tools.Logger.Info(fmt.Sprintf("Invalid 'position' passed: %d", data.Position))
+		return errors.New("'position' requires a positive number")
+	}
+	if data.To < 1 {
+		tools.Logger.Info(fmt.Sprintf("Invalid 'to' passed: %d", data.To))
+		return errors.New("'to' requires a positive number")
+	}
+
+	err := repository.MoveEntry(data.Playlist, data.Position, data.To)
+	if err != nil {
+		if err.Error() == "playlist does not exist" ||
+			err.Error() == "entry does not exist" ||
+			err.Error() == "position out of range" {
+			return err
+		}
+		err = errors.New("failed to move entry")
+		return err
+	}
+
+	return nil
+}
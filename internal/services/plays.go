@@ -0,0 +1,339 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"music/internal/database"
+	"music/tools"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Число мест в чарте по умолчанию
+const defaultChartLimit = 10
+
+// Допустимое расхождение часов клиента: время прослушивания может быть немного впереди
+const playClockSkew = time.Minute
+
+// Прослушивание пользователя
+type PlayData struct {
+	ID       string `json:"id"`
+	Song     string `json:"song"`
+	Group    string `json:"group"`
+	PlayedAt string `json:"playedAt"`
+	Duration int    `json:"duration"`
+}
+
+// Песня в чарте. PreviousRank не передается, если песни не было в предыдущем периоде.
+// Movement - изменение места: new, up, down или same
+type ChartSongData struct {
+	Rank         int    `json:"rank"`
+	PreviousRank int    `json:"previousRank,omitempty"`
+	Movement     string `json:"movement"`
+	ID           string `json:"id"`
+	Song         string `json:"song"`
+	Group        string `json:"group"`
+	Plays        int64  `json:"plays"`
+	Duration     int64  `json:"duration"`
+}
+
+// Группа в чарте
+type ChartGroupData struct {
+	Rank         int    `json:"rank"`
+	PreviousRank int    `json:"previousRank,omitempty"`
+	Movement     string `json:"movement"`
+	Group        string `json:"group"`
+	Plays        int64  `json:"plays"`
+	Duration     int64  `json:"duration"`
+}
+
+// Чарт за период. Границы периодов включительные, в формате DD.MM.YYYY
+type ChartData struct {
+	Period       string           `json:"period"`
+	From         string           `json:"from"`
+	To           string           `json:"to"`
+	PreviousFrom string           `json:"previousFrom"`
+	PreviousTo   string           `json:"previousTo"`
+	Songs        []ChartSongData  `json:"songs"`
+	Groups       []ChartGroupData `json:"groups"`
+}
+
+// Изменение места относительно предыдущего периода
+func movement(rank, previousRank int) string {
+	if previousRank == 0 {
+		return "new"
+	} else if previousRank > rank {
+		return "up"
+	} else if previousRank < rank {
+		return "down"
+	}
+	return "same"
+}
+
+// Разбирает день чарта DD.MM.YYYY, дни считаются по UTC
+func parseChartDay(params url.Values, param string) (time.Time, error) {
+	day, err := time.Parse("2.1.2006", params.Get(param))
+	if err != nil {
+		tools.Logger.Info(fmt.Sprintf("Invalid '%s' format passed: %s", param, params.Get(param)))
+		errorMessage := fmt.Sprintf("'%s' requires a date in format DD.MM.YYYY", param)
+		return day, errors.New(errorMessage)
+	}
+	return day, nil
+}
+
+// Определяет период чарта и предыдущий период, с которым сравниваются места.
+// Для day, week и month предыдущий период - прошлые сутки, неделя или месяц,
+// для from и to - столько же дней непосредственно перед from
+func chartPeriods(params url.Values) (string, database.DateRange, database.DateRange, error) {
+	var period, previous database.DateRange
+
+	if params.Has("from") || params.Has("to") {
+		if params.Has("period") || params.Has("date") {
+			tools.Logger.Info("Chart range passed together with period")
+			return "", period, previous, errors.New("'from' and 'to' can not be combined with 'period' and 'date'")
+		}
+		for _, param := range []string{"from", "to"} {
+			if !params.Has(param) {
+				tools.Logger.Info(fmt.Sprintf("Required parameter '%s' was not passed\n", param))
+				return "", period, previous, fmt.Errorf("'%s' parameter is required", param)
+			}
+		}
+		from, err := parseChartDay(params, "from")
+		if err != nil {
+			return "", period, previous, err
+		}
+		to, err := parseChartDay(params, "to")
+		if err != nil {
+			return "", period, previous, err
+		}
+		if to.Before(from) {
+			tools.Logger.Info(fmt.Sprintf("Chart range ends before it starts: %s - %s", params.Get("from"), params.Get("to")))
+			return "", period, previous, errors.New("'to' must not be earlier than 'from'")
+		}
+
+		period = database.DateRange{From: from, To: to.AddDate(0, 0, 1)}
+		days := int(period.To.Sub(period.From).Hours() / 24)
+		previous = database.DateRange{From: from.AddDate(0, 0, -days), To: from}
+		return "custom", period, previous, nil
+	}
+
+	name := "week"
+	if params.Has("period") {
+		name = params.Get("period")
+	}
+
+	now := time.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if params.Has("date") {
+		var err error
+		day, err = parseChartDay(params, "date")
+		if err != nil {
+			return "", period, previous, err
+		}
+	}
+
+	switch name {
+	case "day":
+		period = database.DateRange{From: day, To: day.AddDate(0, 0, 1)}
+		previous = database.DateRange{From: day.AddDate(0, 0, -1), To: day}
+	case "week":
+		// Неделя начинается с понедельника
+		monday := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		period = database.DateRange{From: monday, To: monday.AddDate(0, 0, 7)}
+		previous = database.DateRange{From: monday.AddDate(0, 0, -7), To: monday}
+	case "month":
+		first := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		period = database.DateRange{From: first, To: first.AddDate(0, 1, 0)}
+		previous = database.DateRange{From: first.AddDate(0, -1, 0), To: first}
+	default:
+		tools.Logger.Info(fmt.Sprintf("Invalid 'period' passed: %s", name))
+		return "", period, previous, errors.New("'period' requires day, week or month")
+	}
+	return name, period, previous, nil
+}
+
+// Записывает прослушивание песни пользователем user
+func RecordPlay(params url.Values, user string) ([]string, error) {
+	expectedParams := map[string]bool{
+		"song":     true,
+		"group":    true,
+		"duration": true,
+		"playedat": true,
+	}
+	requiredParams := map[string]bool{
+		"song":     true,
+		"group":    true,
+		"duration": true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+	for param := range params {
+		if len(params[param]) != 1 {
+			tools.Logger.Info(fmt.Sprintf("To many '%s' parameters was passed\n", param))
+			errorMessage := fmt.Sprintf("'%s' requires only 1 value", param)
+			return unexpectedParams, errors.New(errorMessage)
+		}
+	}
+	if err = checkUser(user); err != nil {
+		return unexpectedParams, err
+	}
+
+	duration, err := strconv.Atoi(params.Get("duration"))
+	if err != nil || duration < 0 {
+		tools.Logger.Info(fmt.Sprintf("Invalid 'duration' format passed: %s", params.Get("duration")))
+		return unexpectedParams, errors.New("'duration' requires a number of seconds, 0 or more")
+	}
+
+	playedAt := time.Now()
+	if params.Has("playedat") {
+		playedAt, err = time.Parse(time.RFC3339, params.Get("playedat"))
+		if err != nil {
+			tools.Logger.Info(fmt.Sprintf("Invalid 'playedat' format passed: %s", params.Get("playedat")))
+			return unexpectedParams, errors.New("'playedat' requires a time in RFC 3339")
+		}
+		if playedAt.After(time.Now().Add(playClockSkew)) {
+			tools.Logger.Info(fmt.Sprintf("Play time in the future passed: %s", params.Get("playedat")))
+			return unexpectedParams, errors.New("'playedat' must not be in the future")
+		}
+	}
+
+	song, group := params.Get("song"), params.Get("group")
+	err = repository.AddPlay(user, song, group, playedAt, duration)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return unexpectedParams, songNotFound(song, group)
+		} else if err.Error() == "user does not exist" {
+			return unexpectedParams, errors.New("unknown user")
+		}
+		err = errors.New("failed to record play")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Получает последние прослушивания пользователя user
+func GetPlays(params url.Values, user string) ([]PlayData, []string, error) {
+	plays := []PlayData{}
+
+	expectedParams := map[string]bool{
+		"limit": true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, map[string]bool{})
+	if err != nil {
+		return plays, unexpectedParams, err
+	}
+	if err = checkUser(user); err != nil {
+		return plays, unexpectedParams, err
+	}
+
+	limit := database.DefaultLimit
+	if params.Has("limit") {
+		limit, err = strconv.Atoi(params.Get("limit"))
+		if err != nil || limit < 1 || limit > maxLimit {
+			tools.Logger.Info(fmt.Sprintf("Invalid 'limit' format passed: %s", params.Get("limit")))
+			errorMessage := fmt.Sprintf("'limit' requires a number from 1 to %d", maxLimit)
+			return plays, unexpectedParams, errors.New(errorMessage)
+		}
+	}
+
+	found, err := repository.ListPlays(user, limit)
+	if err != nil {
+		if err.Error() == "user does not exist" {
+			return plays, unexpectedParams, errors.New("unknown user")
+		}
+		err = errors.New("failed to get plays")
+		return plays, unexpectedParams, err
+	}
+
+	for _, play := range found {
+		plays = append(plays, PlayData{
+			ID:       play.PublicID,
+			Song:     play.Song,
+			Group:    play.Group,
+			PlayedAt: play.PlayedAt.Format(time.RFC3339),
+			Duration: play.Duration,
+		})
+	}
+	return plays, unexpectedParams, nil
+}
+
+// Получает чарт песен и групп за период
+func GetChart(params url.Values) (ChartData, []string, error) {
+	chart := ChartData{Songs: []ChartSongData{}, Groups: []ChartGroupData{}}
+
+	expectedParams := map[string]bool{
+		"period": true,
+		"date":   true,
+		"from":   true,
+		"to":     true,
+		"limit":  true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, map[string]bool{})
+	if err != nil {
+		return chart, unexpectedParams, err
+	}
+	for param := range params {
+		if len(params[param]) != 1 {
+			tools.Logger.Info(fmt.Sprintf("To many '%s' parameters was passed\n", param))
+			errorMessage := fmt.Sprintf("'%s' requires only 1 value", param)
+			return chart, unexpectedParams, errors.New(errorMessage)
+		}
+	}
+
+	limit := defaultChartLimit
+	if params.Has("limit") {
+		limit, err = strconv.Atoi(params.Get("limit"))
+		if err != nil || limit < 1 || limit > maxLimit {
+			tools.Logger.Info(fmt.Sprintf("Invalid 'limit' format passed: %s", params.Get("limit")))
+			errorMessage := fmt.Sprintf("'limit' requires a number from 1 to %d", maxLimit)
+			return chart, unexpectedParams, errors.New(errorMessage)
+		}
+	}
+
+	name, period, previous, err := chartPeriods(params)
+	if err != nil {
+		return chart, unexpectedParams, err
+	}
+
+	found, err := repository.Chart(period, previous, limit)
+	if err != nil {
+		err = errors.New("failed to get chart")
+		return chart, unexpectedParams, err
+	}
+
+	chart.Period = name
+	chart.From = period.From.Format("02.01.2006")
+	chart.To = period.To.AddDate(0, 0, -1).Format("02.01.2006")
+	chart.PreviousFrom = previous.From.Format("02.01.2006")
+	chart.PreviousTo = previous.To.AddDate(0, 0, -1).Format("02.01.2006")
+	for _, song := range found.Songs {
+		chart.Songs = append(chart.Songs, ChartSongData{
+			Rank:         song.Rank,
+			PreviousRank: song.PreviousRank,
+			Movement:     movement(song.Rank, song.PreviousRank),
+			ID:           song.PublicID,
+			Song:         song.Song,
+			Group:        song.Group,
+			Plays:        song.Plays,
+			Duration:     song.Duration,
+		})
+	}
+	for _, group := range found.Groups {
+		chart.Groups = append(chart.Groups, ChartGroupData{
+			Rank:         group.Rank,
+			PreviousRank: group.PreviousRank,
+			Movement:     movement(group.Rank, group.PreviousRank),
+			Group:        group.Group,
+			Plays:        group.Plays,
+			Duration:     group.Duration,
+		})
+	}
+	return chart, unexpectedParams, nil
+}
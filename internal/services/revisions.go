@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"music/internal/database"
+	"music/tools"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ревизия песни
+type Revision struct {
+	Revision    int    `json:"revision"`
+	Operation   string `json:"operation"`
+	Song        string `json:"song"`
+	Group       string `json:"group"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// Проверяет, что переданы только ожидаемые параметры, а обязательные - ровно по одному разу
+func checkParams(params url.Values, expectedParams, requiredParams map[string]bool) ([]string, error) {
+	var unexpectedParams []string
+
+	// Проверка на лишние параметры
+	for param := range params {
+		if _, ok := expectedParams[param]; !ok {
+			unexpectedParams = append(unexpectedParams, param)
+		}
+	}
+
+	// Если нашли лишние параметры
+	if len(unexpectedParams) != 0 {
+		tools.Logger.Info(fmt.Sprintf("Unexpected parameters passed: %s", strings.Join(unexpectedParams, ", ")))
+		err := errors.New("unexpected params")
+		return unexpectedParams, err
+	}
+
+	// Проверка на обязательные параметры
+	for param := range requiredParams {
+		if _, ok := params[param]; !ok {
+			tools.Logger.Info(fmt.Sprintf("Required parameter '%s' was not passed\n", param))
+			errorMessage := fmt.Sprintf("'%s' parameter is required", param)
+			err := errors.New(errorMessage)
+			return unexpectedParams, err
+		} else if len(params[param]) != 1 {
+			tools.Logger.Info(fmt.Sprintf("To many '%s' parameters was passed\n", param))
+			errorMessage := fmt.Sprintf("'%s' requires only 1 value", param)
+			err := errors.New(errorMessage)
+			return unexpectedParams, err
+		}
+	}
+
+	return unexpectedParams, nil
+}
+
+// Разбирает номер ревизии из параметра
+func parseRevision(params url.Values, param string) (int, error) {
+	revision, err := strconv.Atoi(params.Get(param))
+	if err != nil || revision < 1 {
+		tools.Logger.Info(fmt.Sprintf("Invalid '%s' format passed: %s", param, params.Get(param)))
+		errorMessage := fmt.Sprintf("'%s' requires a positive number", param)
+		return 0, errors.New(errorMessage)
+	}
+	return revision, nil
+}
+
+// Получает ревизии песни
+func GetRevisions(params url.Values) ([]Revision, []string, error) {
+	revisions := []Revision{}
+
+	songParams := map[string]bool{
+		"song":  true,
+		"group": true,
+	}
+
+	unexpectedParams, err := checkParams(params, songParams, songParams)
+	if err != nil {
+		return revisions, unexpectedParams, err
+	}
+
+	found, err := repository.ListRevisions(params.Get("song"), params.Get("group"))
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return revisions, unexpectedParams, songNotFound(params.Get("song"), params.Get("group"))
+		}
+		err = errors.New("failed to get revisions")
+		return revisions, unexpectedParams, err
+	}
+
+	for _, revision := range found {
+		revisions = append(revisions, Revision{
+			Revision:    revision.Revision,
+			Operation:   revision.Operation,
+			Song:        revision.Song,
+			Group:       revision.Group,
+			ReleaseDate: revision.ReleaseDate.Format("02.01.2006"),
+			Text:        revision.Text,
+			Link:        revision.Link,
+			CreatedAt:   revision.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return revisions, unexpectedParams, nil
+}
+
+// Построчно сравнивает тексты песни в двух ревизиях
+func DiffRevisions(params url.Values) ([]tools.DiffLine, []string, error) {
+	diff := []tools.DiffLine{}
+
+	expectedParams := map[string]bool{
+		"song":  true,
+		"group": true,
+		"from":  true,
+		"to":    true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, expectedParams)
+	if err != nil {
+		return diff, unexpectedParams, err
+	}
+
+	from, err := parseRevision(params, "from")
+	if err != nil {
+		return diff, unexpectedParams, err
+	}
+	to, err := parseRevision(params, "to")
+	if err != nil {
+		return diff, unexpectedParams, err
+	}
+
+	song, group := params.Get("song"), params.Get("group")
+	texts := []string{}
+	for _, revision := range []int{from, to} {
+		found, err := repository.GetRevision(song, group, revision)
+		if err != nil {
+			if err.Error() == "song does not exist" {
+				return diff, unexpectedParams, songNotFound(song, group)
+			} else if err.Error() == "revision does not exist" {
+				return diff, unexpectedParams, err
+			}
+			err = errors.New("failed to get revisions")
+			return diff, unexpectedParams, err
+		}
+		texts = append(texts, found.Text)
+	}
+
+	return tools.DiffLines(texts[0], texts[1]), unexpectedParams, nil
+}
+
+// Возвращает песню к состоянию из ревизии
+func RevertSong(params url.Values, actor database.Actor) ([]string, error) {
+	expectedParams := map[string]bool{
+		"song":     true,
+		"group":    true,
+		"revision": true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, expectedParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	revision, err := parseRevision(params, "revision")
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	song, group := params.Get("song"), params.Get("group")
+	err = repository.RevertSong(song, group, revision, actor)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return unexpectedParams, songNotFound(song, group)
+		} else if err.Error() == "revision does not exist" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to revert song")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
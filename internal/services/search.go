@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"music/internal/database"
+	"music/tools"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Результат поиска по текстам песен
+type SearchResult struct {
+	ID          string  `json:"id"`
+	Song        string  `json:"song"`
+	Group       string  `json:"group"`
+	ReleaseDate string  `json:"releaseDate"`
+	Rank        float64 `json:"rank"`
+	Snippet     string  `json:"snippet"`
+}
+
+// Дефолтное и максимальное число результатов поиска
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 100
+)
+
+// Ищет песни по строке из текста
+func SearchSongs(params url.Values) ([]SearchResult, []string, error) {
+	results := []SearchResult{}
+
+	expectedParams := map[string]bool{
+		"q":        true,
+		"language": true,
+		"limit":    true,
+	}
+
+	var unexpectedParams []string
+
+	// Проверка на лишние параметры
+	for param := range params {
+		if _, ok := expectedParams[param]; !ok {
+			unexpectedParams = append(unexpectedParams, param)
+		}
+	}
+
+	// Если нашли лишние параметры
+	if len(unexpectedParams) != 0 {
+		tools.Logger.Info(fmt.Sprintf("Unexpected parameters passed: %s", strings.Join(unexpectedParams, ", ")))
+		err := errors.New("unexpected params")
+		return results, unexpectedParams, err
+	}
+
+	// У каждого параметра может быть только одно значение
+	for _, param := range []string{"q", "language", "limit"} {
+		if len(params[param]) > 1 {
+			tools.Logger.Info(fmt.Sprintf("To many '%s' parameters was passed\n", param))
+			err := fmt.Errorf("'%s' requires only 1 value", param)
+			return results, unexpectedParams, err
+		}
+	}
+
+	// Валидация параметра q
+	query := database.SearchQuery{
+		Text:     strings.TrimSpace(params.Get("q")),
+		Language: database.DefaultSearchLanguage,
+		Limit:    defaultSearchLimit,
+	}
+	if query.Text == "" {
+		tools.Logger.Info("Required parameter 'q' was not passed")
+		err := errors.New("'q' parameter is required")
+		return results, unexpectedParams, err
+	}
+
+	// Валидация параметра language
+	if len(params["language"]) != 0 {
+		query.Language = params.Get("language")
+		if !database.IsSearchLanguage(query.Language) {
+			tools.Logger.Info(fmt.Sprintf("Unsupported search language passed: %s", query.Language))
+			err := errors.New("unsupported language")
+			return results, unexpectedParams, err
+		}
+	}
+
+	// Валидация параметра limit
+	if len(params["limit"]) != 0 {
+		limit, err := strconv.Atoi(params.Get("limit"))
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			tools.Logger.Info(fmt.Sprintf("Invalid 'limit' format passed: %s", params.Get("limit")))
+			err := fmt.Errorf("'limit' requires a number from 1 to %d", maxSearchLimit)
+			return results, unexpectedParams, err
+		}
+		query.Limit = limit
+	}
+
+	found, err := repository.SearchText(query)
+	if err != nil {
+		err = errors.New("failed to search songs")
+		return results, unexpectedParams, err
+	}
+
+	for _, result := range found {
+		results = append(results, SearchResult{
+			ID:          result.PublicID,
+			Song:        result.Song,
+			Group:       result.Group,
+			ReleaseDate: result.ReleaseDate.Format("02.01.2006"),
+			Rank:        result.Rank,
+			Snippet:     result.Snippet,
+		})
+	}
+
+	return results, unexpectedParams, nil
+}
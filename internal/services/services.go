@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"music/internal/database"
 	"music/tools"
 	"net/http"
@@ -15,25 +16,91 @@ import (
 )
 
 type SongData struct {
+	ID          string `json:"id,omitempty"`
 	Song        string `json:"song"`
 	Group       string `json:"group"`
 	ReleaseDate string `json:"releaseDate"`
 	Text        string `json:"text"`
 	Link        string `json:"link"`
+	Version     int    `json:"version,omitempty"`
+	// Средняя оценка и число оценок, у неоцененных песен не отдаются
+	Rating  float64 `json:"rating,omitempty"`
+	Ratings int     `json:"ratings,omitempty"`
+	// Альбом песни, приходит из music info API
+	Album *SongAlbumData `json:"album,omitempty"`
+	// Ссылки песни кроме link, приходят из music info API
+	Links []LinkData `json:"links,omitempty"`
 }
 
-// Получает список песен
-func GetSongs(params url.Values) ([]database.SongData, []string, error) {
-	songs := []database.SongData{}
+// Хранилище песен, с которым работают сервисы
+var repository database.SongRepository
+
+// Задает хранилище песен
+func InitRepository(r database.SongRepository) {
+	repository = r
+}
+
+// Число кандидатов в подсказке "возможно, вы имели в виду"
+const suggestionsLimit = 5
+
+// Ошибка "песня не найдена" с похожими песнями
+type NotFoundError struct {
+	Candidates []database.SongCandidate
+}
+
+func (e *NotFoundError) Error() string {
+	return "song does not exist"
+}
+
+// Собирает ошибку "песня не найдена" с ближайшими по названию песнями
+func songNotFound(song, group string) error {
+	candidates, err := repository.SimilarSongs(song, group, suggestionsLimit)
+	if err != nil {
+		tools.Logger.Error(fmt.Sprintf("Failed to find songs similar to '%s' by '%s': ", song, group), err)
+		candidates = nil
+	}
+	return &NotFoundError{Candidates: candidates}
+}
+
+// Максимальное число песен на странице в режиме keyset
+const maxLimit = 100
+
+// Страница списка песен в режиме keyset
+type SongsPage struct {
+	Songs []SongData `json:"songs"`
+	Next  string     `json:"next,omitempty"`
+	Prev  string     `json:"prev,omitempty"`
+}
+
+// Проверяет параметры запроса списка песен и строит по ним фильтр.
+// user - пользователь, от имени которого сделан запрос, нужен для favorites
+func songFilter(params url.Values, user string) (database.SongFilter, []string, error) {
+	filter := database.SongFilter{}
+
+	// Значения параметров ниже заменяются, а favorites удаляется, поэтому работаем с копией
+	params = maps.Clone(params)
 
 	expectedParams := map[string]bool{
-		"song":        true,
-		"group":       true,
-		"releasedate": true,
-		"text":        true,
-		"link":        true,
-		"page":        true,
-		"onpage":      true,
+		"song":         true,
+		"group":        true,
+		"releasedate":  true,
+		"text":         true,
+		"link":         true,
+		"page":         true,
+		"onpage":       true,
+		"cursor":       true,
+		"limit":        true,
+		"sort":         true,
+		"releasedfrom": true,
+		"releasedto":   true,
+		"year":         true,
+		"decade":       true,
+		"album":        true,
+		"artist":       true,
+		"genre":        true,
+		"tag":          true,
+		"provider":     true,
+		"favorites":    true,
 	}
 
 	var unexpectedParams []string
@@ -45,6 +112,12 @@ func GetSongs(params url.Values) ([]database.SongData, []string, error) {
 	// Проверка на лишние параметры
 	for param := range params {
 		if _, ok := expectedParams[param]; !ok {
+			// Фильтры с оператором, например song[contains] или text[icontains]
+			if strings.Contains(param, "[") {
+				if _, _, err := database.ParseFilterParam(param); err == nil {
+					continue
+				}
+			}
 			unexpectedParams = append(unexpectedParams, param)
 		}
 	}
@@ -53,20 +126,20 @@ func GetSongs(params url.Values) ([]database.SongData, []string, error) {
 	if len(unexpectedParams) != 0 {
 		tools.Logger.Info(fmt.Sprintf("Unexpected parameters passed: %s", strings.Join(unexpectedParams, ", ")))
 		err := errors.New("unexpected params")
-		return songs, unexpectedParams, err
+		return filter, unexpectedParams, err
 	}
 
 	// Валидация параметра page
 	if len(params["page"]) > 1 {
 		tools.Logger.Info(fmt.Sprintf("Invalid 'page' format passed: %s", params["page"]))
 		err := errors.New("'page' requires only 1 value")
-		return songs, unexpectedParams, err
+		return filter, unexpectedParams, err
 	} else if len(params["page"]) != 0 {
 		page, err := strconv.Atoi(params["page"][0])
 		if err != nil || page < 1 {
 			tools.Logger.Info(fmt.Sprintf("Invalid 'page' format passed: %s", params["page"][0]))
 			err := errors.New("page is not a number")
-			return songs, unexpectedParams, err
+			return filter, unexpectedParams, err
 		}
 	}
 
@@ -74,31 +147,173 @@ func GetSongs(params url.Values) ([]database.SongData, []string, error) {
 	if len(params["onpage"]) > 1 {
 		tools.Logger.Info(fmt.Sprintf("Invalid 'onpage' format passed: %s", params["onpage"]))
 		err := errors.New("'onpage' requires only 1 value")
-		return songs, unexpectedParams, err
+		return filter, unexpectedParams, err
 	} else if len(params["onpage"]) != 0 {
 		onpage, err := strconv.Atoi(params["onpage"][0])
 		if err != nil || onpage < 1 {
 			tools.Logger.Info(fmt.Sprintf("Invalid 'onpage' format passed: %s", params["onpage"][0]))
 			err := errors.New("onpage is not a number")
-			return songs, unexpectedParams, err
+			return filter, unexpectedParams, err
+		}
+	}
+
+	// Keyset-пагинация не сочетается с page/onpage
+	_, hasCursor := params["cursor"]
+	_, hasLimit := params["limit"]
+	_, hasPage := params["page"]
+	_, hasOnPage := params["onpage"]
+	if (hasCursor || hasLimit) && (hasPage || hasOnPage) {
+		tools.Logger.Info("Both keyset and offset pagination parameters passed")
+		err := errors.New("'cursor' and 'limit' can not be combined with 'page' and 'onpage'")
+		return filter, unexpectedParams, err
+	}
+
+	// Валидация параметра limit
+	if len(params["limit"]) > 1 {
+		tools.Logger.Info(fmt.Sprintf("Invalid 'limit' format passed: %s", params["limit"]))
+		err := errors.New("'limit' requires only 1 value")
+		return filter, unexpectedParams, err
+	} else if len(params["limit"]) != 0 {
+		limit, err := strconv.Atoi(params["limit"][0])
+		if err != nil || limit < 1 || limit > maxLimit {
+			tools.Logger.Info(fmt.Sprintf("Invalid 'limit' format passed: %s", params["limit"][0]))
+			err := errors.New("limit is not a number")
+			return filter, unexpectedParams, err
+		}
+	}
+
+	// Валидация параметра cursor
+	if len(params["cursor"]) > 1 {
+		tools.Logger.Info(fmt.Sprintf("Invalid 'cursor' format passed: %s", params["cursor"]))
+		err := errors.New("'cursor' requires only 1 value")
+		return filter, unexpectedParams, err
+	} else if len(params["cursor"]) != 0 {
+		_, err := database.DecodeCursor(params["cursor"][0])
+		if err != nil {
+			tools.Logger.Info(fmt.Sprintf("Invalid 'cursor' passed: %s", params["cursor"][0]))
+			return filter, unexpectedParams, err
 		}
 	}
 
+	// Валидация параметра sort
+	if _, err := database.ParseSort(params["sort"]); err != nil {
+		tools.Logger.Info(fmt.Sprintf("Invalid 'sort' passed: %s", err))
+		err := errors.New("invalid sort")
+		return filter, unexpectedParams, err
+	}
+
 	// Валидация формата даты
-	if date, ok := params["releasedate"]; ok {
-		_, err := time.Parse("2.1.2006", date[0])
+	for _, date := range params["releasedate"] {
+		_, err := time.Parse("2.1.2006", date)
 		if err != nil {
-			tools.Logger.Info(fmt.Sprintf("Invalid date format passed: %s", date[0]))
+			tools.Logger.Info(fmt.Sprintf("Invalid date format passed: %s", date))
 			err := errors.New("incorrect date format")
-			return songs, unexpectedParams, err
+			return filter, unexpectedParams, err
 		}
 	}
 
-	songs, err := database.ListSongs(params)
+	// Валидация диапазона дат
+	var releasedFrom, releasedTo time.Time
+	for _, param := range []string{"releasedfrom", "releasedto"} {
+		if len(params[param]) > 1 {
+			tools.Logger.Info(fmt.Sprintf("To many '%s' parameters was passed\n", param))
+			errorMessage := fmt.Sprintf("'%s' requires only 1 value", param)
+			err := errors.New(errorMessage)
+			return filter, unexpectedParams, err
+		} else if len(params[param]) != 0 {
+			date, err := time.Parse("2.1.2006", params[param][0])
+			if err != nil {
+				tools.Logger.Info(fmt.Sprintf("Invalid date format passed: %s", params[param][0]))
+				errorMessage := fmt.Sprintf("'%s' requires a date in format DD.MM.YYYY", param)
+				err := errors.New(errorMessage)
+				return filter, unexpectedParams, err
+			}
+			if param == "releasedfrom" {
+				releasedFrom = date
+			} else {
+				releasedTo = date
+			}
+		}
+	}
+	if !releasedFrom.IsZero() && !releasedTo.IsZero() && releasedFrom.After(releasedTo) {
+		tools.Logger.Info("'releasedfrom' is after 'releasedto'")
+		err := errors.New("'releasedfrom' must not be after 'releasedto'")
+		return filter, unexpectedParams, err
+	}
+
+	// Валидация параметров year и decade
+	for _, year := range params["year"] {
+		if _, err := database.ParseYear(year); err != nil {
+			tools.Logger.Info(fmt.Sprintf("Invalid 'year' passed: %s", year))
+			err := errors.New("'year' requires a year, for example 1994")
+			return filter, unexpectedParams, err
+		}
+	}
+	for _, decade := range params["decade"] {
+		if _, err := database.ParseDecade(decade); err != nil {
+			tools.Logger.Info(fmt.Sprintf("Invalid 'decade' passed: %s", decade))
+			err := errors.New("'decade' requires a decade, for example 1990 or 1990s")
+			return filter, unexpectedParams, err
+		}
+	}
+
+	// Валидация параметра provider
+	for _, provider := range params["provider"] {
+		if _, err := database.ParseLinkProvider(provider); err != nil {
+			tools.Logger.Info(fmt.Sprintf("Invalid 'provider' passed: %s", provider))
+			err := errors.New("'provider' requires youtube, spotify, bandcamp, soundcloud, applemusic, deezer or other")
+			return filter, unexpectedParams, err
+		}
+	}
+
+	// Валидация параметра favorites, он не относится к полям фильтра
+	favorites := false
+	if len(params["favorites"]) > 1 {
+		tools.Logger.Info(fmt.Sprintf("Invalid 'favorites' format passed: %s", params["favorites"]))
+		err := errors.New("'favorites' requires only 1 value")
+		return filter, unexpectedParams, err
+	} else if len(params["favorites"]) != 0 {
+		var err error
+		favorites, err = strconv.ParseBool(params["favorites"][0])
+		if err != nil {
+			tools.Logger.Info(fmt.Sprintf("Invalid 'favorites' passed: %s", params["favorites"][0]))
+			err := errors.New("'favorites' requires true or false")
+			return filter, unexpectedParams, err
+		}
+		if favorites && user == "" {
+			tools.Logger.Info("Favorites requested without a user")
+			err := errors.New("no user")
+			return filter, unexpectedParams, err
+		}
+	}
+	delete(params, "favorites")
+
+	// Строим фильтр
+	filter, err := database.NewSongFilter(params)
+	if err != nil {
+		tools.Logger.Info(fmt.Sprintf("Failed to build filter: %s", err))
+		return filter, unexpectedParams, err
+	}
+	if favorites {
+		filter.FavoriteOf = user
+	}
+	return filter, unexpectedParams, nil
+}
+
+// Получает список песен от имени пользователя user, пусто - анонимный запрос
+func GetSongs(params url.Values, user string) (database.SongPage, []string, error) {
+	songs := database.SongPage{}
+
+	filter, unexpectedParams, err := songFilter(params, user)
 	if err != nil {
 		return songs, unexpectedParams, err
 	}
-	return songs, unexpectedParams, nil
+
+	list, err := repository.ListSongs(filter)
+	if err != nil {
+		return songs, unexpectedParams, err
+	}
+	return database.NewSongPage(filter, list), unexpectedParams, nil
 
 }
 
@@ -167,10 +382,10 @@ func GetText(params url.Values) (string, []string, error) {
 	}
 
 	// Получаем текст песни
-	text, err := database.GetText(params["song"][0], params["group"][0])
+	text, err := repository.GetText(params["song"][0], params["group"][0])
 	if err != nil {
 		if err.Error() == "song does not exist" {
-			return text, unexpectedParams, err
+			return text, unexpectedParams, songNotFound(params["song"][0], params["group"][0])
 		} else {
 			err = errors.New("failed to get songs text")
 			return text, unexpectedParams, err
@@ -181,19 +396,23 @@ func GetText(params url.Values) (string, []string, error) {
 	if len(params["verse"]) == 0 {
 		return text, unexpectedParams, nil
 	} else {
-		verses := strings.Split(text, "\n\n")
 		verse, _ := strconv.Atoi(params["verse"][0])
-		if verse > len(verses) {
-			return "", unexpectedParams, nil
-		}
-
-		return verses[verse-1], unexpectedParams, nil
+		return textVerse(text, verse), unexpectedParams, nil
 	}
 
 }
 
+// Куплет текста с номером verse, пустая строка - куплета нет
+func textVerse(text string, verse int) string {
+	verses := strings.Split(text, "\n\n")
+	if verse > len(verses) {
+		return ""
+	}
+	return verses[verse-1]
+}
+
 // Удаляет песню
-func DeleteSong(params url.Values) ([]string, error) {
+func DeleteSong(params url.Values, actor database.Actor) ([]string, error) {
 	requiredParams := map[string]bool{
 		"song":  true,
 		"group": true,
@@ -235,10 +454,10 @@ func DeleteSong(params url.Values) ([]string, error) {
 	}
 
 	// Удаление песни
-	err := database.DeleteSong(params["song"][0], params["group"][0])
+	err := repository.DeleteSong(params["song"][0], params["group"][0], actor)
 	if err != nil {
 		if err.Error() == "song does not exist" {
-			return unexpectedParams, err
+			return unexpectedParams, songNotFound(params["song"][0], params["group"][0])
 		} else {
 			err = errors.New("failed to delete song")
 			return unexpectedParams, err
@@ -248,11 +467,20 @@ func DeleteSong(params url.Values) ([]string, error) {
 	}
 }
 
-// Обновляет информацию о песни
-func UpdateSong(params map[string]string) ([]string, error) {
+// Обновляет информацию о песни. versions - допустимые текущие версии песни, пусто - без проверки.
+// newSong и newGroup переименовывают песню и переносят ее в другую группу.
+// Возвращает новую версию песни
+func UpdateSong(params map[string]string, versions []int, actor database.Actor) (int, []string, error) {
+	return updateSong(params, 0, versions, actor)
+}
+
+// Обновляет песню. Если id не 0, песня ищется по внутреннему id, song и group нужны только для сообщений
+func updateSong(params map[string]string, id int, versions []int, actor database.Actor) (int, []string, error) {
 	expectedParams := map[string]bool{
 		"song":        true,
 		"group":       true,
+		"newSong":     true,
+		"newGroup":    true,
 		"releasedate": true,
 		"text":        true,
 		"link":        true,
@@ -277,7 +505,7 @@ func UpdateSong(params map[string]string) ([]string, error) {
 	if len(unexpectedParams) != 0 {
 		tools.Logger.Info(fmt.Sprintf("Unexpected parameters passed: %s", strings.Join(unexpectedParams, ", ")))
 		err := errors.New("unexpected params")
-		return unexpectedParams, err
+		return 0, unexpectedParams, err
 	}
 
 	// Проверка на обязательные параметры
@@ -286,7 +514,7 @@ func UpdateSong(params map[string]string) ([]string, error) {
 			tools.Logger.Info(fmt.Sprintf("Required parameter '%s' was not passed\n", param))
 			errorMessage := fmt.Sprintf("'%s' parameter is required", param)
 			err := errors.New(errorMessage)
-			return unexpectedParams, err
+			return 0, unexpectedParams, err
 
 		}
 	}
@@ -299,12 +527,23 @@ func UpdateSong(params map[string]string) ([]string, error) {
 			songData.Song = value
 		case "group":
 			songData.Group = value
+		case "newSong", "newGroup":
+			if strings.TrimSpace(value) == "" {
+				tools.Logger.Info(fmt.Sprintf("Empty '%s' parameter was passed\n", param))
+				err := fmt.Errorf("'%s' must not be empty", param)
+				return 0, unexpectedParams, err
+			}
+			if param == "newSong" {
+				songData.NewSong = value
+			} else {
+				songData.NewGroup = value
+			}
 		case "releasedate":
 			releaseDate, err := time.Parse("2.1.2006", value)
 			if err != nil {
 				tools.Logger.Info(fmt.Sprintf("Invalid date format: %s", value))
 				err = errors.New("incorrect date format")
-				return unexpectedParams, err
+				return 0, unexpectedParams, err
 			}
 			songData.ReleaseDate = releaseDate
 		case "text":
@@ -315,21 +554,25 @@ func UpdateSong(params map[string]string) ([]string, error) {
 	}
 
 	// Обновление данных о песни
-	err := database.UpdateSong(songData)
+	songData.ID = id
+	songData.ExpectedVersions = versions
+	newVersion, err := repository.UpdateSong(songData, actor)
 	if err != nil {
-		if err.Error() == "song does not exist" {
-			return unexpectedParams, err
+		if err.Error() == "song was modified" || err.Error() == "song already exists" {
+			return 0, unexpectedParams, err
+		} else if err.Error() == "song does not exist" {
+			return 0, unexpectedParams, songNotFound(songData.Song, songData.Group)
 		} else {
 			err = errors.New("failed to update song")
-			return unexpectedParams, err
+			return 0, unexpectedParams, err
 		}
 	}
 
-	return unexpectedParams, nil
+	return newVersion, unexpectedParams, nil
 }
 
 // Добавляет новую песню
-func AddSong(params url.Values) ([]string, error) {
+func AddSong(params url.Values, actor database.Actor) ([]string, error) {
 	requiredParams := map[string]bool{
 		"song":  true,
 		"group": true,
@@ -370,7 +613,7 @@ func AddSong(params url.Values) ([]string, error) {
 		return unexpectedParams, err
 	}
 	songData := stringToDate(data)
-	err = database.AddSong(songData)
+	err = repository.AddSong(songData, actor)
 	if err != nil {
 		if err.Error() == "song already exists" {
 			return unexpectedParams, err
@@ -404,7 +647,7 @@ func getSongInfo(song, group string) (SongData, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		tools.Logger.Error("Got bad response from song info API: code ", errors.New(string(resp.StatusCode)))
+		tools.Logger.Error("Got bad response from song info API: code ", errors.New(strconv.Itoa(resp.StatusCode)))
 		return songData, errors.New("failed to get song info")
 	}
 	body, err := io.ReadAll(resp.Body)
@@ -430,11 +673,15 @@ func DateToString(songs []database.SongData) []SongData {
 	var result []SongData
 	for _, song := range songs {
 		temp := SongData{}
+		temp.ID = song.PublicID
 		temp.Song = song.Song
 		temp.Group = song.Group
 		temp.ReleaseDate = song.ReleaseDate.Format("02.01.2006")
 		temp.Text = song.Text
 		temp.Link = song.Link
+		temp.Version = song.Version
+		temp.Rating = song.Rating
+		temp.Ratings = song.Ratings
 		result = append(result, temp)
 	}
 	return result
@@ -448,6 +695,8 @@ func stringToDate(data SongData) database.SongData {
 	result.ReleaseDate, _ = time.Parse("02.01.2006", data.ReleaseDate)
 	result.Text = data.Text
 	result.Link = data.Link
+	result.Album = songAlbum(data.Album)
+	result.Links = songLinks(data)
 
 	return result
 }
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"music/internal/database"
+	"music/tools"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Находит песню по публичному id из пути запроса
+func songByID(id string) (database.SongData, error) {
+	publicID, err := database.ParsePublicID(id)
+	if err != nil {
+		tools.Logger.Info(fmt.Sprintf("Invalid song id passed: %s", id))
+		return database.SongData{}, err
+	}
+
+	song, err := repository.SongByPublicID(publicID)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return song, err
+		}
+		err = errors.New("failed to get song")
+		return song, err
+	}
+	return song, nil
+}
+
+// Получает песню по публичному id
+func GetSongByID(id string) (SongData, error) {
+	song, err := songByID(id)
+	if err != nil {
+		return SongData{}, err
+	}
+	return DateToString([]database.SongData{song})[0], nil
+}
+
+// Получает текст песни или один куплет по публичному id
+func GetTextByID(id string, params url.Values) (string, []string, error) {
+	expectedParams := map[string]bool{
+		"verse": true,
+	}
+
+	unexpectedParams, err := checkParams(params, expectedParams, map[string]bool{})
+	if err != nil {
+		return "", unexpectedParams, err
+	}
+
+	verse := 0
+	if params.Has("verse") {
+		if len(params["verse"]) != 1 {
+			tools.Logger.Info("To many 'verse' parameters was passed")
+			return "", unexpectedParams, errors.New("'verse' requires only 1 value")
+		}
+		verse, err = strconv.Atoi(params.Get("verse"))
+		if err != nil || verse < 1 {
+			tools.Logger.Info("Invalid 'verse' format was passed")
+			return "", unexpectedParams, errors.New("'verse' requires a positive number")
+		}
+	}
+
+	song, err := songByID(id)
+	if err != nil {
+		return "", unexpectedParams, err
+	}
+
+	text, err := repository.GetText(song.Song, song.Group)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return "", unexpectedParams, err
+		}
+		err = errors.New("failed to get songs text")
+		return "", unexpectedParams, err
+	}
+
+	if verse == 0 {
+		return text, unexpectedParams, nil
+	}
+	return textVerse(text, verse), unexpectedParams, nil
+}
+
+// Удаляет песню по публичному id. Песня блокируется по внутреннему id, поэтому переименование не мешает
+func DeleteSongByID(id string, actor database.Actor) error {
+	song, err := songByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = repository.DeleteSongByID(song.ID, actor)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return err
+		}
+		err = errors.New("failed to delete song")
+		return err
+	}
+	return nil
+}
+
+// Обновляет песню по публичному id. Песня задается путем, поэтому song и group в теле не принимаются
+func UpdateSongByID(id string, params map[string]string, versions []int, actor database.Actor) (int, []string, error) {
+	unexpectedParams := []string{}
+	for _, param := range []string{"song", "group"} {
+		if _, ok := params[param]; ok {
+			unexpectedParams = append(unexpectedParams, param)
+		}
+	}
+	if len(unexpectedParams) != 0 {
+		tools.Logger.Info(fmt.Sprintf("Unexpected parameters passed: %s", strings.Join(unexpectedParams, ", ")))
+		err := errors.New("unexpected params")
+		return 0, unexpectedParams, err
+	}
+
+	song, err := songByID(id)
+	if err != nil {
+		return 0, unexpectedParams, err
+	}
+
+	// Тело запроса null дает nil map, поэтому параметры копируются в новую
+	update := make(map[string]string, len(params)+2)
+	for param, value := range params {
+		update[param] = value
+	}
+	update["song"], update["group"] = song.Song, song.Group
+	return updateSong(update, song.ID, versions, actor)
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"music/internal/database"
+	"music/tools"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Песня в корзине
+type TrashedSong struct {
+	ID          string `json:"id"`
+	Song        string `json:"song"`
+	Group       string `json:"group"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+	DeletedAt   string `json:"deletedAt"`
+	// Когда песня будет удалена окончательно, пусто, если очистка выключена
+	PurgeAt string `json:"purgeAt,omitempty"`
+}
+
+// Получает содержимое корзины
+func GetTrash(params url.Values) ([]TrashedSong, []string, error) {
+	songs := []TrashedSong{}
+
+	var unexpectedParams []string
+
+	// Корзина не принимает параметров
+	for param := range params {
+		unexpectedParams = append(unexpectedParams, param)
+	}
+
+	// Если нашли лишние параметры
+	if len(unexpectedParams) != 0 {
+		tools.Logger.Info(fmt.Sprintf("Unexpected parameters passed: %s", strings.Join(unexpectedParams, ", ")))
+		err := errors.New("unexpected params")
+		return songs, unexpectedParams, err
+	}
+
+	trashed, err := repository.ListTrash()
+	if err != nil {
+		err = errors.New("failed to get trash")
+		return songs, unexpectedParams, err
+	}
+
+	retention := tools.GetConfig().TrashRetentionDays
+	for _, song := range trashed {
+		temp := TrashedSong{
+			ID:          song.PublicID,
+			Song:        song.Song,
+			Group:       song.Group,
+			ReleaseDate: song.ReleaseDate.Format("02.01.2006"),
+			Text:        song.Text,
+			Link:        song.Link,
+			DeletedAt:   song.DeletedAt.Format(time.RFC3339),
+		}
+		if retention > 0 {
+			temp.PurgeAt = song.DeletedAt.AddDate(0, 0, retention).Format(time.RFC3339)
+		}
+		songs = append(songs, temp)
+	}
+
+	return songs, unexpectedParams, nil
+}
+
+// Восстанавливает песню из корзины
+func RestoreSong(params url.Values, actor database.Actor) ([]string, error) {
+	requiredParams := map[string]bool{
+		"song":  true,
+		"group": true,
+	}
+
+	var unexpectedParams []string
+
+	// Проверка на лишние параметры
+	for param := range params {
+		if _, ok := requiredParams[param]; !ok {
+			unexpectedParams = append(unexpectedParams, param)
+		}
+	}
+
+	// Если нашли лишние параметры
+	if len(unexpectedParams) != 0 {
+		tools.Logger.Info(fmt.Sprintf("Unexpected parameters passed: %s", strings.Join(unexpectedParams, ", ")))
+		err := errors.New("unexpected params")
+		return unexpectedParams, err
+	}
+
+	// Валидация пераметров
+	for param := range requiredParams {
+		if _, ok := params[param]; !ok {
+			tools.Logger.Info(fmt.Sprintf("Required parameter '%s' was not passed\n", param))
+			errorMessage := fmt.Sprintf("'%s' parameter is required", param)
+			err := errors.New(errorMessage)
+			return unexpectedParams, err
+		} else if len(params[param]) != 1 {
+			tools.Logger.Info(fmt.Sprintf("To many '%s' parameters was passed\n", param))
+			errorMessage := fmt.Sprintf("'%s' requires only 1 value", param)
+			err := errors.New(errorMessage)
+			return unexpectedParams, err
+		}
+	}
+
+	// Восстановление песни
+	err := repository.RestoreSong(params["song"][0], params["group"][0], actor)
+	if err != nil {
+		if err.Error() == "song is not in trash" || err.Error() == "song already exists" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to restore song")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Окончательно удаляет песни, пролежавшие в корзине дольше срока хранения
+func PurgeTrash() error {
+	retention := tools.GetConfig().TrashRetentionDays
+	if retention <= 0 {
+		return nil
+	}
+
+	_, err := repository.PurgeTrash(time.Now().AddDate(0, 0, -retention))
+	if err != nil {
+		return errors.New("failed to purge trash")
+	}
+	return nil
+}
+
+// Периодически очищает корзину
+func RunTrashPurge() {
+	interval := tools.GetConfig().TrashPurgeInterval
+	for {
+		err := PurgeTrash()
+		if err != nil {
+			tools.Logger.Error("Failed to purge trash: ", err)
+		}
+		time.Sleep(interval)
+	}
+}
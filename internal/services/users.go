@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"music/internal/database"
+	"music/tools"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Пользователь с числом избранных и оцененных песен
+type UserData struct {
+	User      string `json:"user"`
+	CreatedAt string `json:"createdAt"`
+	Favorites int    `json:"favorites"`
+	Ratings   int    `json:"ratings"`
+}
+
+// Оценки песни после изменения
+type RatingData struct {
+	Rating  float64 `json:"rating"`
+	Ratings int     `json:"ratings"`
+}
+
+// Проверяет, что запрос сделан от имени пользователя
+func checkUser(user string) error {
+	if user == "" {
+		tools.Logger.Info("Request without a user")
+		return errors.New("no user")
+	}
+	return nil
+}
+
+// Регистрирует пользователя
+func AddUser(params url.Values) ([]string, error) {
+	requiredParams := map[string]bool{
+		"user": true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+
+	name := strings.TrimSpace(params.Get("user"))
+	if name == "" {
+		tools.Logger.Info("Empty user name passed")
+		return unexpectedParams, errors.New("empty user name")
+	}
+
+	err = repository.AddUser(name)
+	if err != nil {
+		if err.Error() == "user already exists" {
+			return unexpectedParams, err
+		}
+		err = errors.New("failed to add user")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Получает пользователя
+func GetUser(params url.Values) (UserData, []string, error) {
+	user := UserData{}
+
+	requiredParams := map[string]bool{
+		"user": true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return user, unexpectedParams, err
+	}
+
+	found, err := repository.GetUser(params.Get("user"))
+	if err != nil {
+		if err.Error() == "user does not exist" {
+			return user, unexpectedParams, err
+		}
+		err = errors.New("failed to get user")
+		return user, unexpectedParams, err
+	}
+
+	user = UserData{
+		User:      found.Name,
+		CreatedAt: found.CreatedAt.Format(time.RFC3339),
+		Favorites: found.Favorites,
+		Ratings:   found.Ratings,
+	}
+	return user, unexpectedParams, nil
+}
+
+// Добавляет песню в избранное пользователя user или убирает из него
+func SetFavorite(params url.Values, user string, favorite bool) ([]string, error) {
+	requiredParams := map[string]bool{
+		"song":  true,
+		"group": true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return unexpectedParams, err
+	}
+	if err = checkUser(user); err != nil {
+		return unexpectedParams, err
+	}
+
+	song, group := params.Get("song"), params.Get("group")
+	err = repository.SetFavorite(user, song, group, favorite)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return unexpectedParams, songNotFound(song, group)
+		} else if err.Error() == "user does not exist" {
+			return unexpectedParams, errors.New("unknown user")
+		}
+		err = errors.New("failed to update favorites")
+		return unexpectedParams, err
+	}
+
+	return unexpectedParams, nil
+}
+
+// Ставит песне оценку пользователя user
+func RateSong(params url.Values, user string) (RatingData, []string, error) {
+	requiredParams := map[string]bool{
+		"song":   true,
+		"group":  true,
+		"rating": true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return RatingData{}, unexpectedParams, err
+	}
+
+	rating, err := strconv.Atoi(params.Get("rating"))
+	if err != nil || rating < 1 || rating > database.MaxRating {
+		tools.Logger.Info(fmt.Sprintf("Invalid 'rating' passed: %s", params.Get("rating")))
+		errorMessage := fmt.Sprintf("'rating' requires a number from 1 to %d", database.MaxRating)
+		return RatingData{}, unexpectedParams, errors.New(errorMessage)
+	}
+
+	return rateSong(params, user, rating, unexpectedParams)
+}
+
+// Убирает оценку пользователя user
+func UnrateSong(params url.Values, user string) (RatingData, []string, error) {
+	requiredParams := map[string]bool{
+		"song":  true,
+		"group": true,
+	}
+
+	unexpectedParams, err := checkParams(params, requiredParams, requiredParams)
+	if err != nil {
+		return RatingData{}, unexpectedParams, err
+	}
+
+	return rateSong(params, user, 0, unexpectedParams)
+}
+
+// Меняет оценку песни, 0 - убирает оценку
+func rateSong(params url.Values, user string, rating int, unexpectedParams []string) (RatingData, []string, error) {
+	if err := checkUser(user); err != nil {
+		return RatingData{}, unexpectedParams, err
+	}
+
+	song, group := params.Get("song"), params.Get("group")
+	average, count, err := repository.RateSong(user, song, group, rating)
+	if err != nil {
+		if err.Error() == "song does not exist" {
+			return RatingData{}, unexpectedParams, songNotFound(song, group)
+		} else if err.Error() == "user does not exist" {
+			return RatingData{}, unexpectedParams, errors.New("unknown user")
+		}
+		err = errors.New("failed to rate song")
+		return RatingData{}, unexpectedParams, err
+	}
+
+	return RatingData{Rating: average, Ratings: count}, unexpectedParams, nil
+}
@@ -1 +1 @@
-DROP TABLE IF EXISTS "Group";
\ No newline at end of file
+DROP TABLE IF EXISTS "Group";
@@ -1 +1 @@
-DROP TABLE IF EXISTS "Song";
\ No newline at end of file
+DROP TABLE IF EXISTS "Song";
@@ -1 +1 @@
-DROP INDEX idx_group_name;
\ No newline at end of file
+DROP INDEX IF EXISTS idx_group_name;
@@ -1 +1 @@
-DROP INDEX idx_song_name;
\ No newline at end of file
+DROP INDEX IF EXISTS idx_song_name;
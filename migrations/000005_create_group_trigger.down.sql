@@ -1 +1,2 @@
-DROP TRIGGER IF EXISTS trigger_delete_empty_group ON "Song";
\ No newline at end of file
+DROP TRIGGER IF EXISTS trigger_delete_empty_group ON "Song";
+DROP FUNCTION IF EXISTS delete_empty_group();
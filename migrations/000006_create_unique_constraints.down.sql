@@ -0,0 +1,2 @@
+ALTER TABLE "Song" DROP CONSTRAINT IF EXISTS uq_song_group_name;
+ALTER TABLE "Group" DROP CONSTRAINT IF EXISTS uq_group_name;
@@ -0,0 +1,23 @@
+-- Переносим песни из групп-дубликатов в группу с наименьшим id
+UPDATE "Song" s
+SET group_id = d.keep_id
+FROM (
+    SELECT group_id, MIN(group_id) OVER (PARTITION BY name) AS keep_id
+    FROM "Group"
+) d
+WHERE s.group_id = d.group_id AND d.group_id <> d.keep_id;
+
+-- Удаляем опустевшие группы-дубликаты
+DELETE FROM "Group" g
+USING "Group" k
+WHERE g.name = k.name AND g.group_id > k.group_id;
+
+-- Песни-дубликаты внутри группы не удаляются, а переименовываются: к названию добавляется
+-- id песни, чтобы их можно было разобрать вручную. Длина названия остается в пределах 255
+UPDATE "Song" s
+SET name = left(s.name, 240) || ' (#' || s.song_id || ')'
+FROM "Song" k
+WHERE s.group_id = k.group_id AND s.name = k.name AND s.song_id > k.song_id;
+
+ALTER TABLE "Group" ADD CONSTRAINT uq_group_name UNIQUE (name);
+ALTER TABLE "Song" ADD CONSTRAINT uq_song_group_name UNIQUE (group_id, name);
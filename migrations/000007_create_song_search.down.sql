@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_song_text_search_russian;
+DROP INDEX IF EXISTS idx_song_text_search_english;
+DROP INDEX IF EXISTS idx_song_text_search_simple;
+
+ALTER TABLE "Song"
+    DROP COLUMN IF EXISTS text_search_russian,
+    DROP COLUMN IF EXISTS text_search_english,
+    DROP COLUMN IF EXISTS text_search_simple;
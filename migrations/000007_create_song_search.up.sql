@@ -0,0 +1,12 @@
+-- Поисковые векторы текста песни для каждой поддерживаемой конфигурации языка
+ALTER TABLE "Song"
+    ADD COLUMN IF NOT EXISTS text_search_simple tsvector
+        GENERATED ALWAYS AS (to_tsvector('simple'::regconfig, coalesce("text", ''))) STORED,
+    ADD COLUMN IF NOT EXISTS text_search_english tsvector
+        GENERATED ALWAYS AS (to_tsvector('english'::regconfig, coalesce("text", ''))) STORED,
+    ADD COLUMN IF NOT EXISTS text_search_russian tsvector
+        GENERATED ALWAYS AS (to_tsvector('russian'::regconfig, coalesce("text", ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_song_text_search_simple ON "Song" USING GIN (text_search_simple);
+CREATE INDEX IF NOT EXISTS idx_song_text_search_english ON "Song" USING GIN (text_search_english);
+CREATE INDEX IF NOT EXISTS idx_song_text_search_russian ON "Song" USING GIN (text_search_russian);
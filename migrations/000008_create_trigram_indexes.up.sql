@@ -0,0 +1,4 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_song_name_trgm ON "Song" USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_group_name_trgm ON "Group" USING GIN (name gin_trgm_ops);
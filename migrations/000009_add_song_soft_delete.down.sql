@@ -0,0 +1,8 @@
+-- Песни из корзины удаляются окончательно
+DELETE FROM "Song" WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_song_deleted_at;
+DROP INDEX IF EXISTS uq_song_group_name_active;
+ALTER TABLE "Song" ADD CONSTRAINT uq_song_group_name UNIQUE (group_id, name);
+
+ALTER TABLE "Song" DROP COLUMN IF EXISTS deleted_at;
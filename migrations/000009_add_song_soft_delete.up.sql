@@ -0,0 +1,7 @@
+ALTER TABLE "Song" ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Уникальны только неудаленные песни, в корзине может лежать несколько версий
+ALTER TABLE "Song" DROP CONSTRAINT IF EXISTS uq_song_group_name;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_song_group_name_active ON "Song" (group_id, name) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_song_deleted_at ON "Song" (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS "SongRevision" (
+    revision_id SERIAL PRIMARY KEY,
+    song_id INT NOT NULL,
+    revision INT NOT NULL,
+    operation VARCHAR(16) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    group_name VARCHAR(255) NOT NULL,
+    release_date DATE NOT NULL,
+    text TEXT,
+    link VARCHAR(2048),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT fk_song FOREIGN KEY (song_id)
+        REFERENCES "Song" (song_id)
+        ON DELETE CASCADE,
+    CONSTRAINT uq_song_revision UNIQUE (song_id, revision)
+);
+
+-- Первая ревизия для уже существующих песен
+INSERT INTO "SongRevision" (song_id, revision, operation, name, group_name, release_date, text, link)
+SELECT s.song_id, 1, 'create', s.name, g.name, s.release_date, s.text, s.link
+FROM "Song" s
+JOIN "Group" g ON s.group_id = g.group_id
+ON CONFLICT DO NOTHING;
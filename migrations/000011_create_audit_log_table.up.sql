@@ -0,0 +1,20 @@
+-- Имена песни и группы хранятся в записи, чтобы журнал переживал окончательное удаление песни
+CREATE TABLE IF NOT EXISTS "AuditLog" (
+    audit_id SERIAL PRIMARY KEY,
+    song_id INT,
+    song_name VARCHAR(255) NOT NULL,
+    group_name VARCHAR(255) NOT NULL,
+    operation VARCHAR(16) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    request_id VARCHAR(255) NOT NULL,
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT fk_song FOREIGN KEY (song_id)
+        REFERENCES "Song" (song_id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_song ON "AuditLog" (song_name, group_name);
+CREATE INDEX IF NOT EXISTS idx_audit_actor ON "AuditLog" (actor);
+CREATE INDEX IF NOT EXISTS idx_audit_created_at ON "AuditLog" (created_at);
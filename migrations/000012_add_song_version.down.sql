@@ -0,0 +1 @@
+ALTER TABLE "Song" DROP COLUMN IF EXISTS version;
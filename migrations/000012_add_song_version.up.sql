@@ -0,0 +1,2 @@
+-- Версия песни увеличивается при каждом изменении и отдается клиентам как ETag
+ALTER TABLE "Song" ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS "Album" (
+    album_id SERIAL PRIMARY KEY,
+    group_id INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    release_date DATE,
+    album_type VARCHAR(16) NOT NULL DEFAULT 'LP',
+    CONSTRAINT fk_group FOREIGN KEY (group_id)
+        REFERENCES "Group" (group_id)
+        ON DELETE CASCADE,
+    CONSTRAINT uq_album_group_title UNIQUE (group_id, title),
+    CONSTRAINT chk_album_type CHECK (album_type IN ('LP', 'EP', 'single', 'compilation'))
+);
+
+-- Место песни на альбоме
+CREATE TABLE IF NOT EXISTS "AlbumTrack" (
+    album_id INT NOT NULL,
+    song_id INT NOT NULL,
+    disc_number INT NOT NULL DEFAULT 1,
+    track_number INT NOT NULL,
+    PRIMARY KEY (album_id, song_id),
+    CONSTRAINT fk_album FOREIGN KEY (album_id)
+        REFERENCES "Album" (album_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_song FOREIGN KEY (song_id)
+        REFERENCES "Song" (song_id)
+        ON DELETE CASCADE,
+    CONSTRAINT uq_album_track UNIQUE (album_id, disc_number, track_number),
+    CONSTRAINT chk_track_numbers CHECK (disc_number > 0 AND track_number > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_album_track_song ON "AlbumTrack" (song_id);
+CREATE INDEX IF NOT EXISTS idx_album_title ON "Album" (title);
+
+-- Группа с альбомами не считается пустой
+CREATE OR REPLACE FUNCTION delete_empty_group()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM "Song" WHERE group_id = OLD.group_id
+    ) AND NOT EXISTS (
+        SELECT 1 FROM "Album" WHERE group_id = OLD.group_id
+    ) THEN
+        DELETE FROM "Group" WHERE group_id = OLD.group_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,16 @@
+CREATE OR REPLACE FUNCTION delete_empty_group()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM "Song" WHERE group_id = OLD.group_id
+    ) AND NOT EXISTS (
+        SELECT 1 FROM "Album" WHERE group_id = OLD.group_id
+    ) THEN
+        DELETE FROM "Group" WHERE group_id = OLD.group_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS "Membership";
+DROP TABLE IF EXISTS "Person";
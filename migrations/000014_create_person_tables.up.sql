@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS "Person" (
+    person_id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    CONSTRAINT uq_person_name UNIQUE (name)
+);
+
+-- Участие человека в группе. Пустые даты - период открыт с этой стороны
+CREATE TABLE IF NOT EXISTS "Membership" (
+    membership_id SERIAL PRIMARY KEY,
+    person_id INT NOT NULL,
+    group_id INT NOT NULL,
+    role VARCHAR(64) NOT NULL,
+    active_from DATE,
+    active_to DATE,
+    CONSTRAINT fk_person FOREIGN KEY (person_id)
+        REFERENCES "Person" (person_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_group FOREIGN KEY (group_id)
+        REFERENCES "Group" (group_id)
+        ON DELETE CASCADE,
+    CONSTRAINT chk_membership_period CHECK (active_to IS NULL OR active_from IS NULL OR active_from <= active_to)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_membership
+    ON "Membership" (person_id, group_id, role, coalesce(active_from, '-infinity'::date));
+CREATE INDEX IF NOT EXISTS idx_membership_group ON "Membership" (group_id);
+
+-- Группа с альбомами или участниками не считается пустой
+CREATE OR REPLACE FUNCTION delete_empty_group()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM "Song" WHERE group_id = OLD.group_id
+    ) AND NOT EXISTS (
+        SELECT 1 FROM "Album" WHERE group_id = OLD.group_id
+    ) AND NOT EXISTS (
+        SELECT 1 FROM "Membership" WHERE group_id = OLD.group_id
+    ) THEN
+        DELETE FROM "Group" WHERE group_id = OLD.group_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "SongTag";
+DROP TABLE IF EXISTS "Tag";
+DROP TABLE IF EXISTS "SongGenre";
+DROP TABLE IF EXISTS "Genre";
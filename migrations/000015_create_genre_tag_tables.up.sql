@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS "Genre" (
+    genre_id SERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    CONSTRAINT uq_genre_name UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS "SongGenre" (
+    song_id INT NOT NULL,
+    genre_id INT NOT NULL,
+    PRIMARY KEY (song_id, genre_id),
+    CONSTRAINT fk_song FOREIGN KEY (song_id)
+        REFERENCES "Song" (song_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_genre FOREIGN KEY (genre_id)
+        REFERENCES "Genre" (genre_id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "Tag" (
+    tag_id SERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    CONSTRAINT uq_tag_name UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS "SongTag" (
+    song_id INT NOT NULL,
+    tag_id INT NOT NULL,
+    PRIMARY KEY (song_id, tag_id),
+    CONSTRAINT fk_song FOREIGN KEY (song_id)
+        REFERENCES "Song" (song_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_tag FOREIGN KEY (tag_id)
+        REFERENCES "Tag" (tag_id)
+        ON DELETE CASCADE
+);
+
+-- Фильтр по жанру или тегу идет от названия к песням
+CREATE INDEX IF NOT EXISTS idx_song_genre_genre ON "SongGenre" (genre_id);
+CREATE INDEX IF NOT EXISTS idx_song_tag_tag ON "SongTag" (tag_id);
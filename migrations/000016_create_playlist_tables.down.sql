@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "PlaylistEntry";
+DROP TABLE IF EXISTS "Playlist";
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS "Playlist" (
+    playlist_id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT uq_playlist_name UNIQUE (name)
+);
+
+-- Позиции в плейлисте идут подряд с единицы. Проверка уникальности отложена
+-- до конца транзакции, чтобы при сдвиге позиций не было промежуточных конфликтов
+CREATE TABLE IF NOT EXISTS "PlaylistEntry" (
+    entry_id SERIAL PRIMARY KEY,
+    playlist_id INT NOT NULL,
+    song_id INT NOT NULL,
+    position INT NOT NULL,
+    CONSTRAINT fk_playlist FOREIGN KEY (playlist_id)
+        REFERENCES "Playlist" (playlist_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_song FOREIGN KEY (song_id)
+        REFERENCES "Song" (song_id)
+        ON DELETE CASCADE,
+    CONSTRAINT uq_playlist_position UNIQUE (playlist_id, position) DEFERRABLE INITIALLY DEFERRED,
+    CONSTRAINT chk_position CHECK (position > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_playlist_entry_song ON "PlaylistEntry" (song_id);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "Rating";
+DROP TABLE IF EXISTS "Favorite";
+DROP TABLE IF EXISTS "User";
+DROP FUNCTION IF EXISTS update_song_rating();
+DROP INDEX IF EXISTS idx_song_rating;
+ALTER TABLE "Song"
+    DROP COLUMN IF EXISTS rating_avg,
+    DROP COLUMN IF EXISTS rating_sum,
+    DROP COLUMN IF EXISTS rating_count;
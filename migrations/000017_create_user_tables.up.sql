@@ -0,0 +1,85 @@
+CREATE TABLE IF NOT EXISTS "User" (
+    user_id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT uq_user_name UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS "Favorite" (
+    user_id INT NOT NULL,
+    song_id INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, song_id),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES "User" (user_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_song FOREIGN KEY (song_id)
+        REFERENCES "Song" (song_id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "Rating" (
+    user_id INT NOT NULL,
+    song_id INT NOT NULL,
+    rating SMALLINT NOT NULL,
+    rated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, song_id),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES "User" (user_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_song FOREIGN KEY (song_id)
+        REFERENCES "Song" (song_id)
+        ON DELETE CASCADE,
+    CONSTRAINT chk_rating CHECK (rating BETWEEN 1 AND 5)
+);
+
+CREATE INDEX IF NOT EXISTS idx_favorite_song ON "Favorite" (song_id);
+CREATE INDEX IF NOT EXISTS idx_rating_song ON "Rating" (song_id);
+
+-- Агрегаты оценок хранятся в песне, чтобы список песен не пересчитывал их на каждый запрос.
+-- Средняя оценка без оценок равна 0
+ALTER TABLE "Song"
+    ADD COLUMN IF NOT EXISTS rating_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rating_sum INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rating_avg NUMERIC(3, 2) NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_song_rating ON "Song" (rating_avg, song_id);
+
+-- Агрегаты меняются на разницу, а не пересчитываются: UPDATE перечитывает
+-- заблокированную строку песни, поэтому параллельные оценки не теряются
+CREATE OR REPLACE FUNCTION update_song_rating()
+RETURNS TRIGGER AS $$
+DECLARE
+    target_song INT;
+    count_delta INT := 0;
+    sum_delta INT := 0;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        target_song := NEW.song_id;
+        count_delta := 1;
+        sum_delta := NEW.rating;
+    ELSIF TG_OP = 'DELETE' THEN
+        target_song := OLD.song_id;
+        count_delta := -1;
+        sum_delta := -OLD.rating;
+    ELSE
+        target_song := NEW.song_id;
+        sum_delta := NEW.rating - OLD.rating;
+    END IF;
+
+    UPDATE "Song" SET
+        rating_count = rating_count + count_delta,
+        rating_sum = rating_sum + sum_delta,
+        rating_avg = CASE
+            WHEN rating_count + count_delta = 0 THEN 0
+            ELSE round((rating_sum + sum_delta)::numeric / (rating_count + count_delta), 2)
+        END
+    WHERE song_id = target_song;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_update_song_rating
+AFTER INSERT OR UPDATE OF rating OR DELETE ON "Rating"
+FOR EACH ROW
+EXECUTE FUNCTION update_song_rating();
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "PlayDaily";
+DROP TABLE IF EXISTS "Play";
+DROP FUNCTION IF EXISTS reject_play_update();
+DROP FUNCTION IF EXISTS rollup_play();
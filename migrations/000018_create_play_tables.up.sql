@@ -0,0 +1,62 @@
+-- Прослушивания только добавляются, изменять их нельзя
+CREATE TABLE IF NOT EXISTS "Play" (
+    play_id BIGSERIAL PRIMARY KEY,
+    song_id INT NOT NULL,
+    user_id INT NOT NULL,
+    played_at TIMESTAMPTZ NOT NULL,
+    duration INT NOT NULL,
+    CONSTRAINT fk_song FOREIGN KEY (song_id)
+        REFERENCES "Song" (song_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES "User" (user_id)
+        ON DELETE CASCADE,
+    CONSTRAINT chk_duration CHECK (duration >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_play_song ON "Play" (song_id);
+CREATE INDEX IF NOT EXISTS idx_play_user ON "Play" (user_id, played_at DESC, play_id DESC);
+
+-- Суммы прослушиваний песни за сутки по UTC. Чарты строятся только по этой таблице,
+-- поэтому их стоимость зависит от числа дней и песен, а не от числа прослушиваний
+CREATE TABLE IF NOT EXISTS "PlayDaily" (
+    day DATE NOT NULL,
+    song_id INT NOT NULL,
+    plays BIGINT NOT NULL DEFAULT 0,
+    duration BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, song_id),
+    CONSTRAINT fk_song FOREIGN KEY (song_id)
+        REFERENCES "Song" (song_id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_play_daily_song ON "PlayDaily" (song_id);
+
+CREATE OR REPLACE FUNCTION rollup_play()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO "PlayDaily" (day, song_id, plays, duration)
+    VALUES ((NEW.played_at AT TIME ZONE 'UTC')::date, NEW.song_id, 1, NEW.duration)
+    ON CONFLICT (day, song_id) DO UPDATE SET
+        plays = "PlayDaily".plays + 1,
+        duration = "PlayDaily".duration + EXCLUDED.duration;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_rollup_play
+AFTER INSERT ON "Play"
+FOR EACH ROW
+EXECUTE FUNCTION rollup_play();
+
+CREATE OR REPLACE FUNCTION reject_play_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'plays are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_reject_play_update
+BEFORE UPDATE ON "Play"
+FOR EACH ROW
+EXECUTE FUNCTION reject_play_update();
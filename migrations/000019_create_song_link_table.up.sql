@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS "SongLink" (
+    link_id SERIAL PRIMARY KEY,
+    song_id INT NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    label VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT uq_song_link UNIQUE (song_id, url),
+    CONSTRAINT fk_song FOREIGN KEY (song_id)
+        REFERENCES "Song" (song_id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_song_link_provider ON "SongLink" (provider, song_id);
+
+-- Переносим существующие ссылки песен с пустым provider. Приложение при запуске
+-- определяет сервис и приводит ссылки к каноническому виду так же, как новые ссылки
+INSERT INTO "SongLink" (song_id, provider, url)
+SELECT song_id, '', link
+FROM "Song"
+WHERE link IS NOT NULL AND link <> ''
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_song_public_id;
+ALTER TABLE "Song" DROP COLUMN IF EXISTS public_id;
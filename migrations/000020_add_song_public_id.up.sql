@@ -0,0 +1,5 @@
+-- Публичный id песни не меняется при переименовании и переносе в другую группу.
+-- Существующие песни получают id при добавлении колонки, gen_random_uuid встроена в PostgreSQL 13+
+ALTER TABLE "Song" ADD COLUMN IF NOT EXISTS public_id UUID NOT NULL DEFAULT gen_random_uuid();
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_song_public_id ON "Song" (public_id);
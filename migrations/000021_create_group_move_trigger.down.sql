@@ -0,0 +1 @@
+DROP TRIGGER IF EXISTS trigger_delete_empty_group_on_move ON "Song";
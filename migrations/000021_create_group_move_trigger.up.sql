@@ -0,0 +1,6 @@
+-- Группа, из которой перенесли последнюю песню, удаляется так же, как при удалении песни
+CREATE TRIGGER trigger_delete_empty_group_on_move
+AFTER UPDATE OF group_id ON "Song"
+FOR EACH ROW
+WHEN (OLD.group_id IS DISTINCT FROM NEW.group_id)
+EXECUTE FUNCTION delete_empty_group();
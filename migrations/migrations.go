@@ -0,0 +1,7 @@
+// Пакет встраивает SQL-миграции в исполняемый файл
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -25,10 +25,17 @@ func infoHandler(writer http.ResponseWriter, request *http.Request) {
 	}
 
 	if song == "Roads" && group == "Portishead" {
-		songInfo := map[string]string{}
+		songInfo := map[string]any{}
 		songInfo["releaseDate"] = "22.08.1994"
 		songInfo["link"] = "https://www.youtube.com/watch?v=Vg1jyL3cr60"
 		songInfo["text"] = text
+		songInfo["album"] = map[string]any{
+			"title":       "Dummy",
+			"releaseDate": "22.08.1994",
+			"type":        "LP",
+			"disc":        1,
+			"track":       7,
+		}
 
 		writer.WriteHeader(200)
 		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
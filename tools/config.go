@@ -1,7 +1,12 @@
 package tools
 
 import (
+	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +20,22 @@ type Config struct {
 	ServerAddr    string
 	MusicInfoAddr string
 	LogLevel      string
+	LogFile       string
+	Storage       string
+	// Применять миграции при запуске сервера
+	AutoMigrate bool
+
+	// Настройки пула соединений с БД
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+	DBPingOnStart     bool
+
+	// Через сколько дней песни из корзины удаляются окончательно, 0 - никогда
+	TrashRetentionDays int
+	// Как часто проверять корзину
+	TrashPurgeInterval time.Duration
 }
 
 var config *Config
@@ -23,10 +44,24 @@ var config *Config
 func GetConfig() *Config {
 	if config == nil {
 		config = new(Config)
-		err := godotenv.Load("../.env")
-		if err != nil {
-			panic("no .env file provided!")
+
+		// Путь к .env можно задать переменной окружения ENVFILE. Файл по умолчанию ищется
+		// рядом с исполняемым файлом, затем в рабочем каталоге (при go run исполняемый файл
+		// лежит во временном каталоге). Без файла настройки берутся только из окружения
+		envFile := os.Getenv("ENVFILE")
+		if envFile == "" {
+			envFile = findEnvFile(nearExecutable(".env"), ".env")
 		}
+		if envFile != "" {
+			err := godotenv.Load(envFile)
+			if err != nil {
+				panic("can't load env file " + envFile + ": " + err.Error())
+			}
+		} else {
+			// Логгер еще не настроен, поэтому предупреждение пишется в stderr
+			log.Println("warning: .env file not found, using environment variables only")
+		}
+
 		config.Host = os.Getenv("PGHOST")
 		config.Port = os.Getenv("PGPORT")
 		config.DBName = os.Getenv("DBNAME")
@@ -35,7 +70,107 @@ func GetConfig() *Config {
 		config.ServerAddr = os.Getenv("SERVER")
 		config.MusicInfoAddr = os.Getenv("MUSICINFO")
 		config.LogLevel = os.Getenv("LOGLEVEL")
+		config.LogFile = os.Getenv("LOGFILE")
+		if config.LogFile == "" {
+			config.LogFile = defaultLogFile()
+		}
+		config.Storage = os.Getenv("STORAGE")
+		config.AutoMigrate = getBool("AUTOMIGRATE", true)
+		config.DBMaxOpenConns = getInt("DBMAXOPENCONNS", 10)
+		config.DBMaxIdleConns = getInt("DBMAXIDLECONNS", 5)
+		config.DBConnMaxLifetime = getDuration("DBCONNMAXLIFETIME", 30*time.Minute)
+		config.DBConnMaxIdleTime = getDuration("DBCONNMAXIDLETIME", 5*time.Minute)
+		config.DBPingOnStart = getBool("DBPINGONSTART", true)
+		config.TrashRetentionDays = getInt("TRASHRETENTIONDAYS", 30)
+		config.TrashPurgeInterval = getDuration("TRASHPURGEINTERVAL", time.Hour)
+		// Очистка корзины повторяется с этим интервалом, ноль превратил бы ее в цикл без пауз
+		if config.TrashPurgeInterval <= 0 {
+			panic("invalid TRASHPURGEINTERVAL value: " + os.Getenv("TRASHPURGEINTERVAL") + ", must be positive")
+		}
 	}
 
 	return config
 }
+
+// Путь к файлу в каталоге над исполняемым файлом: для cmd/server это корень
+// репозитория, для /bin/server в контейнере - корень файловой системы
+func nearExecutable(name string) string {
+	executable, err := os.Executable()
+	if err != nil {
+		return filepath.Join("..", name)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+	return filepath.Join(filepath.Dir(executable), "..", name)
+}
+
+// Файл логов по умолчанию - уровнем выше исполняемого файла. При go run исполняемый файл
+// собирается во временном каталоге и удаляется, поэтому лог пишется в рабочий каталог
+func defaultLogFile() string {
+	if executable, err := os.Executable(); err == nil && inDir(executable, os.TempDir()) {
+		return "app.log"
+	}
+	return nearExecutable("app.log")
+}
+
+// Лежит ли path внутри каталога dir, с учетом символических ссылок
+func inDir(path, dir string) bool {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Первый существующий файл из candidates, пусто - ни одного нет
+func findEnvFile(candidates ...string) string {
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// Читает целое число из переменной окружения
+func getInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		panic("invalid " + key + " value: " + value)
+	}
+	return result
+}
+
+// Читает длительность (например, "30m") из переменной окружения
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	result, err := time.ParseDuration(value)
+	if err != nil {
+		panic("invalid " + key + " value: " + value)
+	}
+	return result
+}
+
+// Читает логическое значение из переменной окружения
+func getBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		panic("invalid " + key + " value: " + value)
+	}
+	return result
+}